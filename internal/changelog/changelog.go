@@ -0,0 +1,310 @@
+package changelog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+)
+
+// Unreleased is the version of commits not tagged yet.
+const Unreleased = "Unreleased"
+
+// Entry is one commit in a release.
+type Entry struct {
+	Type           string `json:"type"`
+	Scope          string `json:"scope,omitempty"`
+	Title          string `json:"title"`
+	Hash           string `json:"hash"`
+	Breaking       bool   `json:"breaking,omitempty"`
+	BreakingChange string `json:"breaking_change,omitempty"`
+}
+
+// Section groups the entries of one commit type.
+type Section struct {
+	Title   string  `json:"title"`
+	Entries []Entry `json:"entries"`
+}
+
+// Release is the changelog of one version.
+type Release struct {
+	Version  string    `json:"version"`
+	Date     string    `json:"date,omitempty"`
+	Breaking []Entry   `json:"breaking,omitempty"`
+	Sections []Section `json:"sections"`
+}
+
+// Sections of the types that matter to users, in this order
+var sectionTitles = []struct{ commitType, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+}
+
+// Build groups the conventional commits into sections. Commits of other
+// types are only included with all, titled by their description in types.
+// Commits that aren't conventional are skipped.
+func Build(version, date string, commits []gitpkg.Commit, types []model.CommitType, all bool) Release {
+	release := Release{Version: version, Date: date}
+
+	bySection := make(map[string][]Entry)
+	var order []string
+	for _, s := range sectionTitles {
+		order = append(order, s.commitType)
+	}
+
+	for _, c := range commits {
+		if lint.Ignored(c.Message) {
+			continue
+		}
+		info := model.ParseMessage(c.Message)
+		if info.Type == "" {
+			continue
+		}
+
+		entry := Entry{
+			Type:           info.Type,
+			Scope:          info.Scope,
+			Title:          info.Title,
+			Hash:           c.Hash,
+			Breaking:       info.Breaking || info.BreakingChange != "",
+			BreakingChange: info.BreakingChange,
+		}
+		if entry.Breaking {
+			release.Breaking = append(release.Breaking, entry)
+		}
+
+		if _, ok := bySection[entry.Type]; !ok && !slices.Contains(order, entry.Type) {
+			if !all {
+				continue
+			}
+			order = append(order, entry.Type)
+		}
+		bySection[entry.Type] = append(bySection[entry.Type], entry)
+	}
+
+	for _, commitType := range order {
+		entries := bySection[commitType]
+		if len(entries) == 0 {
+			continue
+		}
+		release.Sections = append(release.Sections, Section{Title: sectionTitle(commitType, types), Entries: entries})
+	}
+	return release
+}
+
+func sectionTitle(commitType string, types []model.CommitType) string {
+	for _, s := range sectionTitles {
+		if s.commitType == commitType {
+			return s.title
+		}
+	}
+	for _, t := range types {
+		if t.Key == commitType {
+			return t.Description
+		}
+	}
+	return commitType
+}
+
+// IsEmpty reports whether the release has nothing to list.
+func (r Release) IsEmpty() bool {
+	return len(r.Sections) == 0 && len(r.Breaking) == 0
+}
+
+// Heading renders the "## [version] - date" line, as Keep a Changelog
+// lays it out.
+func (r Release) Heading() string {
+	if r.Date == "" {
+		return fmt.Sprintf("## [%s]", r.Version)
+	}
+	return fmt.Sprintf("## [%s] - %s", r.Version, r.Date)
+}
+
+// block is a "### title" subsection of a release and its entry lines.
+type block struct {
+	title string
+	lines []string
+}
+
+func (r Release) blocks() []block {
+	var blocks []block
+
+	if len(r.Breaking) > 0 {
+		b := block{title: "Breaking Changes"}
+		for _, e := range r.Breaking {
+			note := e.BreakingChange
+			if note == "" {
+				note = e.Title
+			}
+			b.lines = append(b.lines, line(e.Scope, note, e.Hash))
+		}
+		blocks = append(blocks, b)
+	}
+
+	for _, s := range r.Sections {
+		b := block{title: s.Title}
+		for _, e := range s.Entries {
+			b.lines = append(b.lines, line(e.Scope, e.Title, e.Hash))
+		}
+		blocks = append(blocks, b)
+	}
+
+	return blocks
+}
+
+// Markdown renders the release as a changelog section.
+func (r Release) Markdown() string {
+	var b strings.Builder
+	b.WriteString(r.Heading() + "\n")
+
+	for _, bl := range r.blocks() {
+		b.WriteString("\n### " + bl.title + "\n")
+		for _, l := range bl.lines {
+			b.WriteString(l)
+		}
+	}
+
+	return b.String()
+}
+
+// Text renders the release as plain text without Markdown headings, for
+// tag messages where git strips lines starting with '#'.
+func (r Release) Text() string {
+	var b strings.Builder
+
+	if len(r.Breaking) > 0 {
+		b.WriteString("Breaking Changes:\n")
+		for _, e := range r.Breaking {
+			note := e.BreakingChange
+			if note == "" {
+				note = e.Title
+			}
+			b.WriteString(plainLine(e.Scope, note))
+		}
+	}
+
+	for _, s := range r.Sections {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(s.Title + ":\n")
+		for _, e := range s.Entries {
+			b.WriteString(plainLine(e.Scope, e.Title))
+		}
+	}
+
+	return b.String()
+}
+
+func plainLine(scope, text string) string {
+	if scope != "" {
+		text = scope + ": " + text
+	}
+	return "- " + text + "\n"
+}
+
+func line(scope, text, hash string) string {
+	if scope != "" {
+		text = fmt.Sprintf("**%s:** %s", scope, text)
+	}
+	if hash != "" {
+		text += fmt.Sprintf(" (%s)", hash[:min(7, len(hash))])
+	}
+	return "- " + text + "\n"
+}
+
+// Prepend inserts the release before the first release of an existing
+// changelog, or after an Unreleased section when the release is a version,
+// replacing a release with the same version. An Unreleased release is
+// merged into an existing Unreleased section, which may be kept by hand.
+// Without any release the section is appended after the preamble.
+func Prepend(existing string, r Release) string {
+	section := r.Markdown()
+	if existing == "" {
+		return "# Changelog\n\n" + section
+	}
+
+	lines := strings.SplitAfter(existing, "\n")
+	var headings []int
+	for i, l := range lines {
+		if strings.HasPrefix(l, "## ") {
+			headings = append(headings, i)
+		}
+	}
+	headings = append(headings, len(lines))
+
+	next := 0
+	if first := headings[0]; first < len(lines) && strings.HasPrefix(lines[first], "## ["+Unreleased+"]") {
+		if r.Version == Unreleased {
+			end := headings[1]
+			return strings.Join(lines[:first], "") + merge(lines[first:end], r, end < len(lines)) + strings.Join(lines[end:], "")
+		}
+		next = 1
+	}
+	at, end := headings[next], headings[next]
+	if at < len(lines) && strings.HasPrefix(lines[at], "## ["+r.Version+"]") {
+		end = headings[next+1]
+	}
+
+	before := strings.Join(lines[:at], "")
+	if !strings.HasSuffix(before, "\n\n") {
+		before = strings.TrimRight(before, "\n") + "\n\n"
+	}
+	after := strings.Join(lines[end:], "")
+	if after != "" {
+		section += "\n"
+	}
+	return before + section + after
+}
+
+// merge adds the entries of r missing from section, the lines of an
+// existing release, to its subsection of the same title or else to a new
+// one at the end. more tells whether another release follows.
+func merge(section []string, r Release, more bool) string {
+	section = slices.Clone(section)
+	for len(section) > 1 && strings.TrimSpace(section[len(section)-1]) == "" {
+		section = section[:len(section)-1]
+	}
+	if last := len(section) - 1; !strings.HasSuffix(section[last], "\n") {
+		section[last] += "\n"
+	}
+
+	for _, bl := range r.blocks() {
+		var missing []string
+		for _, l := range bl.lines {
+			if !slices.Contains(section, l) {
+				missing = append(missing, l)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		at := slices.Index(section, "### "+bl.title+"\n")
+		if at < 0 {
+			section = append(section, "\n", "### "+bl.title+"\n")
+			section = append(section, missing...)
+			continue
+		}
+
+		// After the last line of the subsection
+		end := at + 1
+		for end < len(section) && !strings.HasPrefix(section[end], "#") {
+			end++
+		}
+		for end > at+1 && strings.TrimSpace(section[end-1]) == "" {
+			end--
+		}
+		section = slices.Insert(section, end, missing...)
+	}
+
+	text := strings.Join(section, "")
+	if more {
+		text += "\n"
+	}
+	return text
+}
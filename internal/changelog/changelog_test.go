@@ -0,0 +1,104 @@
+package changelog
+
+import "testing"
+
+const (
+	preamble = "# Changelog\n\nAll notable changes to this project are documented here.\n"
+	previous = "## [1.0.0] - 2026-09-01\n\n### Features\n- add the version flag (1234567)\n"
+)
+
+func fixes(version, date string) Release {
+	return Release{
+		Version: version,
+		Date:    date,
+		Sections: []Section{{
+			Title:   "Bug Fixes",
+			Entries: []Entry{{Type: "fix", Scope: "cli", Title: "handle empty input", Hash: "abcdef0123456789"}},
+		}},
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		release  Release
+		want     string
+	}{
+		{
+			name:    "empty file",
+			release: fixes("1.1.0", "2026-10-01"),
+			want:    "# Changelog\n\n## [1.1.0] - 2026-10-01\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n",
+		},
+		{
+			name:     "preamble only",
+			existing: preamble,
+			release:  fixes("1.1.0", "2026-10-01"),
+			want:     preamble + "\n## [1.1.0] - 2026-10-01\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n",
+		},
+		{
+			name:     "before a release",
+			existing: preamble + "\n" + previous,
+			release:  fixes("1.1.0", "2026-10-01"),
+			want:     preamble + "\n## [1.1.0] - 2026-10-01\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "replacing the same version",
+			existing: preamble + "\n## [1.1.0] - 2026-09-30\n\n### Bug Fixes\n- an early draft (7654321)\n\n" + previous,
+			release:  fixes("1.1.0", "2026-10-01"),
+			want:     preamble + "\n## [1.1.0] - 2026-10-01\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "replacing the last version",
+			existing: preamble + "\n" + previous,
+			release:  fixes("1.0.0", "2026-09-02"),
+			want:     preamble + "\n## [1.0.0] - 2026-09-02\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n",
+		},
+		{
+			name:     "version after Unreleased",
+			existing: preamble + "\n## [Unreleased]\n\n### Features\n- written by hand\n\n" + previous,
+			release:  fixes("1.1.0", "2026-10-01"),
+			want: preamble + "\n## [Unreleased]\n\n### Features\n- written by hand\n\n" +
+				"## [1.1.0] - 2026-10-01\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "Unreleased into the same subsection",
+			existing: preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- written by hand\n\n" + previous,
+			release:  fixes(Unreleased, ""),
+			want:     preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- written by hand\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "Unreleased into a new subsection",
+			existing: preamble + "\n## [Unreleased]\n\n### Features\n- written by hand\n\n" + previous,
+			release:  fixes(Unreleased, ""),
+			want: preamble + "\n## [Unreleased]\n\n### Features\n- written by hand\n\n" +
+				"### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "Unreleased at the end",
+			existing: preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- written by hand\n\n\n",
+			release:  fixes(Unreleased, ""),
+			want:     preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- written by hand\n- **cli:** handle empty input (abcdef0)\n",
+		},
+		{
+			name:     "Unreleased already listed",
+			existing: preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+			release:  fixes(Unreleased, ""),
+			want:     preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+		{
+			name:     "Unreleased without an Unreleased section",
+			existing: preamble + "\n" + previous,
+			release:  fixes(Unreleased, ""),
+			want:     preamble + "\n## [Unreleased]\n\n### Bug Fixes\n- **cli:** handle empty input (abcdef0)\n\n" + previous,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Prepend(tt.existing, tt.release); got != tt.want {
+				t.Errorf("Prepend =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package changes
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern.
+// "**" matches any number of directories, e.g. "internal/**/*_test.go",
+// and a pattern without a slash matches the file name at any depth, like
+// in .gitignore.
+func MatchGlob(pattern, p string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,175 @@
+package changes
+
+import (
+	"path"
+	"strings"
+
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+// Group is a set of changes proposed as one commit.
+type Group struct {
+	Items  []model.GitChange
+	Type   string // suggested commit type, "" if nothing stands out
+	Reason string // why Type was suggested
+	Scope  string
+}
+
+// Paths returns the paths the group's changes touch, including the
+// sources of renames.
+func (g Group) Paths() []string {
+	var paths []string
+	for _, it := range g.Items {
+		paths = append(paths, it.Path)
+		if it.OrigPath != "" {
+			paths = append(paths, it.OrigPath)
+		}
+	}
+	return paths
+}
+
+// Header returns "type(scope)", "type" or "" for the suggestion.
+func (g Group) Header() string {
+	if g.Type == "" || g.Scope == "" {
+		return g.Type
+	}
+	return g.Type + "(" + g.Scope + ")"
+}
+
+const (
+	// Paths committed together at least this often end up in one group
+	coChangeMin = 2
+	// Commits touching more files, like renames or reformats, say little
+	// about which files belong together
+	coChangeMaxFiles = 20
+)
+
+// GroupChanges clusters changes into proposed commits. Files of one kind
+// that has its own commit type (docs, CI, module files or a configured
+// type rule) go together; other files are grouped by package directory,
+// which keeps tests next to their implementation, and packages that were
+// committed together in history, newest first as from ChangedPaths, are
+// joined. Each group carries the type it suggests and, for code, a scope.
+func GroupChanges(items []model.GitChange, history [][]string, rules []config.TypeRule, loadDiff DiffLoader) []Group {
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			// The earlier change stays the root so groups keep status order
+			if rb < ra {
+				ra, rb = rb, ra
+			}
+			parent[rb] = ra
+		}
+	}
+
+	// Same kind or same package
+	keys := make([]string, len(items))
+	first := make(map[string]int)
+	for i, it := range items {
+		keys[i] = clusterKey(it.Path, rules)
+		if j, ok := first[keys[i]]; ok {
+			union(i, j)
+		} else {
+			first[keys[i]] = i
+		}
+	}
+
+	// Co-change history joins packages, never files of a kind
+	byPath := make(map[string][]int)
+	for i, it := range items {
+		if strings.HasPrefix(keys[i], "dir:") {
+			byPath[it.Path] = append(byPath[it.Path], i)
+			if it.OrigPath != "" {
+				byPath[it.OrigPath] = append(byPath[it.OrigPath], i)
+			}
+		}
+	}
+
+	counts := make(map[[2]int]int)
+	for _, paths := range history {
+		if len(paths) > coChangeMaxFiles {
+			continue
+		}
+
+		var touched []int
+		for _, p := range paths {
+			touched = append(touched, byPath[p]...)
+		}
+		for a := 0; a < len(touched); a++ {
+			for b := a + 1; b < len(touched); b++ {
+				i, j := touched[a], touched[b]
+				if keys[i] == keys[j] {
+					continue
+				}
+				if i > j {
+					i, j = j, i
+				}
+				pair := [2]int{i, j}
+				if counts[pair]++; counts[pair] == coChangeMin {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	var groups []Group
+	index := make(map[int]int)
+	for i, it := range items {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, Group{})
+		}
+		groups[g].Items = append(groups[g].Items, it)
+	}
+
+	for root, i := range index {
+		groups[i].Type, groups[i].Reason = SuggestType(groups[i].Items, rules, loadDiff)
+
+		// The type already says what docs or CI changes are about
+		if strings.HasPrefix(keys[root], "dir:") {
+			if scopes := SuggestScopes(groups[i].Items); len(scopes) > 0 {
+				groups[i].Scope = scopes[0]
+			}
+		}
+	}
+
+	return groups
+}
+
+// clusterKey returns "type:<type>" for files whose kind has a commit type
+// of its own and "dir:<package directory>" for the rest. Test files and
+// testdata belong to the package they test.
+func clusterKey(p string, rules []config.TypeRule) string {
+	for _, rule := range typeRules(rules) {
+		if rule.Type == "test" {
+			continue
+		}
+		for _, pattern := range rule.Paths {
+			if MatchGlob(pattern, p) {
+				return "type:" + rule.Type
+			}
+		}
+	}
+
+	dir := path.Dir(p)
+	if before, _, ok := strings.Cut(p, "/testdata/"); ok {
+		dir = before
+	} else if strings.HasPrefix(p, "testdata/") {
+		dir = "."
+	}
+	return "dir:" + dir
+}
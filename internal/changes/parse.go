@@ -1,31 +1,105 @@
 package changes
 
 import (
-	"bufio"
+	"strconv"
 	"strings"
 
 	"gcm/internal/model"
 )
 
+// ParseChangedFiles parses the NUL-delimited output of
+// `git status --porcelain=v2 -z`. Paths are taken verbatim, so names with
+// spaces, newlines or non-ASCII characters survive unquoted.
 func ParseChangedFiles(output string) []model.GitChange {
 	var changes []model.GitChange
 
-	scanner := bufio.NewScanner(strings.NewReader(output))
+	records := strings.Split(output, "\x00")
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) < 3 {
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 2 {
 			continue
 		}
 
-		change := model.GitChange{
-			Index:   line[0],
-			Working: line[1],
-			Path:    strings.TrimSpace(line[2:]),
-		}
+		switch record[0] {
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) < 9 {
+				continue
+			}
+			change := parseEntry(fields[1], fields[2], fields[3], fields[4], fields[5])
+			change.Path = fields[8]
+			changes = append(changes, change)
+
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>\0<origPath>
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) < 10 {
+				continue
+			}
+			change := parseEntry(fields[1], fields[2], fields[3], fields[4], fields[5])
+			change.Path = fields[9]
+			change.Score, _ = strconv.Atoi(fields[8][1:])
+			if i+1 < len(records) {
+				i++
+				change.OrigPath = records[i]
+			}
+			changes = append(changes, change)
 
-		changes = append(changes, change)
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+			// stage 2 ("ours") stands in for HEAD; the index holds several stages
+			change := parseEntry(fields[1], fields[2], fields[4], "", fields[6])
+			change.Path = fields[10]
+			change.Unmerged = true
+			changes = append(changes, change)
+
+		case '?':
+			changes = append(changes, model.GitChange{
+				Index:   '?',
+				Working: '?',
+				Path:    record[2:],
+			})
+		}
+		// '!' (ignored) and '#' (header) records are skipped
 	}
 
 	return changes
 }
+
+func parseEntry(xy, sub, modeHead, modeIndex, modeWorktree string) model.GitChange {
+	return model.GitChange{
+		Index:        statusCode(xy[0]),
+		Working:      statusCode(xy[1]),
+		Submodule:    parseSubmodule(sub),
+		ModeHead:     modeHead,
+		ModeIndex:    modeIndex,
+		ModeWorktree: modeWorktree,
+	}
+}
+
+// statusCode maps porcelain v2's '.' (unmodified) to the space used by v1,
+// which the rest of gcm keys on.
+func statusCode(c byte) byte {
+	if c == '.' {
+		return ' '
+	}
+	return c
+}
+
+func parseSubmodule(sub string) model.SubmoduleState {
+	if len(sub) != 4 || sub[0] != 'S' {
+		return model.SubmoduleState{}
+	}
+	return model.SubmoduleState{
+		IsSubmodule:   true,
+		CommitChanged: sub[1] == 'C',
+		Modified:      sub[2] == 'M',
+		Untracked:     sub[3] == 'U',
+	}
+}
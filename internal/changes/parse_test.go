@@ -0,0 +1,123 @@
+package changes
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gcm/internal/model"
+)
+
+const (
+	hashZero = "0000000000000000000000000000000000000000"
+	hashA    = "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"
+	hashB    = "5716ca5987cbf97d6bb54920bea6adde242d87e6"
+	hashC    = "7c4a013e52c76442ab80ee5572399a30373600a2"
+)
+
+// status joins records the way `git status --porcelain=v2 -z` does.
+func status(records ...string) string {
+	return strings.Join(records, "\x00") + "\x00"
+}
+
+func TestParseChangedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []model.GitChange
+	}{
+		{name: "empty", output: ""},
+		{
+			name:   "ordinary change with a space in the path",
+			output: status("1 .M N... 100644 100644 100644 " + hashA + " " + hashA + " docs/release notes.md"),
+			want: []model.GitChange{{
+				Index: ' ', Working: 'M', Path: "docs/release notes.md",
+				ModeHead: "100644", ModeIndex: "100644", ModeWorktree: "100644",
+			}},
+		},
+		{
+			name:   "ordinary change with a newline in the path",
+			output: status("1 A. N... 000000 100755 100755 " + hashZero + " " + hashA + " bin/odd\nname.sh"),
+			want: []model.GitChange{{
+				Index: 'A', Working: ' ', Path: "bin/odd\nname.sh",
+				ModeHead: "000000", ModeIndex: "100755", ModeWorktree: "100755",
+			}},
+		},
+		{
+			name:   "rename",
+			output: status("2 R. N... 100644 100644 100644 "+hashA+" "+hashA+" R100 internal/new name.go", "internal/old name.go"),
+			want: []model.GitChange{{
+				Index: 'R', Working: ' ', Path: "internal/new name.go", OrigPath: "internal/old name.go", Score: 100,
+				ModeHead: "100644", ModeIndex: "100644", ModeWorktree: "100644",
+			}},
+		},
+		{
+			name: "copy followed by a change",
+			output: status(
+				"2 C. N... 100644 100644 100644 "+hashA+" "+hashA+" C75 b.go", "a.go",
+				"1 .M N... 100644 100644 100644 "+hashA+" "+hashA+" a.go",
+			),
+			want: []model.GitChange{
+				{Index: 'C', Working: ' ', Path: "b.go", OrigPath: "a.go", Score: 75, ModeHead: "100644", ModeIndex: "100644", ModeWorktree: "100644"},
+				{Index: ' ', Working: 'M', Path: "a.go", ModeHead: "100644", ModeIndex: "100644", ModeWorktree: "100644"},
+			},
+		},
+		{
+			name:   "unmerged",
+			output: status("u UU N... 100644 100644 100755 100755 " + hashA + " " + hashB + " " + hashC + " conflict me.go"),
+			want: []model.GitChange{{
+				Index: 'U', Working: 'U', Path: "conflict me.go", Unmerged: true,
+				ModeHead: "100644", ModeWorktree: "100755",
+			}},
+		},
+		{
+			name:   "submodule",
+			output: status("1 .M S.M. 160000 160000 160000 " + hashA + " " + hashA + " vendor/lib"),
+			want: []model.GitChange{{
+				Index: ' ', Working: 'M', Path: "vendor/lib",
+				Submodule: model.SubmoduleState{IsSubmodule: true, Modified: true},
+				ModeHead:  "160000", ModeIndex: "160000", ModeWorktree: "160000",
+			}},
+		},
+		{
+			name:   "submodule with a new commit and untracked files",
+			output: status("1 .M SC.U 160000 160000 160000 " + hashA + " " + hashA + " vendor/lib"),
+			want: []model.GitChange{{
+				Index: ' ', Working: 'M', Path: "vendor/lib",
+				Submodule: model.SubmoduleState{IsSubmodule: true, CommitChanged: true, Untracked: true},
+				ModeHead:  "160000", ModeIndex: "160000", ModeWorktree: "160000",
+			}},
+		},
+		{
+			name:   "untracked",
+			output: status("? notes/to do.txt", "? build/"),
+			want: []model.GitChange{
+				{Index: '?', Working: '?', Path: "notes/to do.txt"},
+				{Index: '?', Working: '?', Path: "build/"},
+			},
+		},
+		{
+			name: "headers and ignored files",
+			output: status(
+				"# branch.oid "+hashA,
+				"# branch.head main",
+				"! bin/gcm",
+				"? new.go",
+			),
+			want: []model.GitChange{{Index: '?', Working: '?', Path: "new.go"}},
+		},
+		{
+			name:   "truncated record",
+			output: status("1 .M N... 100644 100644"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseChangedFiles(tt.output)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseChangedFiles = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
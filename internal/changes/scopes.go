@@ -0,0 +1,62 @@
+package changes
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gcm/internal/model"
+)
+
+// Directories that only group code and make poor scopes on their own
+var containerDirs = map[string]bool{
+	"internal": true,
+	"pkg":      true,
+	"cmd":      true,
+	"src":      true,
+	"lib":      true,
+}
+
+// SuggestScopes derives candidate commit scopes from changed paths: the
+// top-level directory of each path and, for Go files, the package
+// directory name. The most frequent candidates come first.
+func SuggestScopes(items []model.GitChange) []string {
+	counts := make(map[string]int)
+	var order []string
+
+	add := func(scope string) {
+		scope = strings.ToLower(scope)
+		if scope == "" || scope == "." {
+			return
+		}
+		if counts[scope] == 0 {
+			order = append(order, scope)
+		}
+		counts[scope]++
+	}
+
+	for _, it := range items {
+		p := strings.TrimSuffix(it.Path, "/")
+		dir := path.Dir(p)
+		if dir == "." {
+			continue
+		}
+
+		top, rest, _ := strings.Cut(dir, "/")
+		if containerDirs[top] && rest != "" {
+			// internal/auth/... is better described by "auth"
+			top, _, _ = strings.Cut(rest, "/")
+		}
+		add(top)
+
+		if strings.HasSuffix(p, ".go") && path.Base(dir) != top {
+			add(path.Base(dir))
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+
+	return order
+}
@@ -0,0 +1,38 @@
+package changes
+
+import "gcm/internal/model"
+
+// SplitStaged splits every change that has both staged and unstaged edits
+// into two entries, one for the index and one for the working tree, so each
+// side can be selected on its own.
+func SplitStaged(items []model.GitChange) []model.GitChange {
+	var res []model.GitChange
+	for _, it := range items {
+		if !it.IsStaged() || it.Working == ' ' {
+			res = append(res, it)
+			continue
+		}
+
+		staged := it
+		staged.Working = ' '
+
+		unstaged := it
+		unstaged.Index = ' '
+		unstaged.OrigPath = ""
+		unstaged.Score = 0
+
+		res = append(res, staged, unstaged)
+	}
+	return res
+}
+
+// Staged returns the entries that are already in the index.
+func Staged(items []model.GitChange) []model.GitChange {
+	var res []model.GitChange
+	for _, it := range items {
+		if it.IsStaged() {
+			res = append(res, it)
+		}
+	}
+	return res
+}
@@ -0,0 +1,136 @@
+package changes
+
+import (
+	"strings"
+	"unicode"
+
+	"gcm/internal/config"
+	"gcm/internal/diff"
+	"gcm/internal/model"
+)
+
+// Built-in suggestions; a configured rule for the same type replaces them
+var defaultTypeRules = []config.TypeRule{
+	{Type: "test", Paths: []string{"*_test.go", "**/testdata/**"}, Reason: "only test files changed"},
+	{Type: "docs", Paths: []string{"*.md", "docs/**"}, Reason: "only documentation changed"},
+	{Type: "ci", Paths: []string{".github/workflows/**", ".gitlab-ci.yml", ".circleci/**", ".travis.yml"}, Reason: "only CI configuration changed"},
+	{Type: "build", Paths: []string{"go.mod", "go.sum"}, Reason: "only module files changed"},
+}
+
+// DiffLoader returns the diff a change contributes to the commit.
+type DiffLoader func(change model.GitChange) (string, error)
+
+// SuggestType guesses the commit type of a set of changes and explains
+// why, or returns "" when nothing stands out. Configured rules come first,
+// then the built-in path rules; changes whose diffs only touch whitespace
+// suggest "style". loadDiff may be nil to skip that check.
+func SuggestType(items []model.GitChange, rules []config.TypeRule, loadDiff DiffLoader) (string, string) {
+	if len(items) == 0 {
+		return "", ""
+	}
+
+	for _, rule := range typeRules(rules) {
+		if allMatch(items, rule.Paths) {
+			reason := rule.Reason
+			if reason == "" {
+				reason = "the changed paths match " + strings.Join(rule.Paths, ", ")
+			}
+			return rule.Type, reason
+		}
+	}
+
+	if loadDiff != nil && whitespaceOnly(items, loadDiff) {
+		return "style", "only whitespace changed"
+	}
+
+	return "", ""
+}
+
+func typeRules(configured []config.TypeRule) []config.TypeRule {
+	rules := append([]config.TypeRule(nil), configured...)
+	for _, rule := range defaultTypeRules {
+		overridden := false
+		for _, c := range configured {
+			if c.Type == rule.Type {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func allMatch(items []model.GitChange, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	for _, it := range items {
+		matched := false
+		for _, pattern := range patterns {
+			if MatchGlob(pattern, it.Path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// whitespaceOnly reports whether every change modifies an existing file
+// and its removed and added lines differ only in whitespace. Binary and
+// mode-only diffs have no lines to compare and don't count.
+func whitespaceOnly(items []model.GitChange, loadDiff DiffLoader) bool {
+	for _, it := range items {
+		if it.DisplayType() != "MODIFIED" {
+			return false
+		}
+
+		text := it.Patch
+		if text == "" {
+			var err error
+			if text, err = loadDiff(it); err != nil {
+				return false
+			}
+		}
+
+		f := diff.Parse(text)
+		if f.Binary() {
+			return false
+		}
+		if added, removed := f.Stat(); added == 0 && removed == 0 {
+			return false
+		}
+
+		var removed, added strings.Builder
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				switch l.Kind {
+				case '-':
+					removed.WriteString(stripSpace(l.Text))
+				case '+':
+					added.WriteString(stripSpace(l.Text))
+				}
+			}
+		}
+		if removed.String() != added.String() {
+			return false
+		}
+	}
+	return true
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,173 @@
+package changes
+
+import (
+	"errors"
+	"testing"
+
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+func modified(path string) model.GitChange {
+	return model.GitChange{Index: ' ', Working: 'M', Path: path}
+}
+
+const (
+	reindented = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ func main() {
+-    run()
++	run()
+ }
+`
+	edited = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ func main() {
+-	run()
++	run(ctx)
+ }
+`
+	binary = `diff --git a/logo.png b/logo.png
+index 1234567..89abcde 100644
+Binary files a/logo.png and b/logo.png differ
+`
+	modeOnly = `diff --git a/build.sh b/build.sh
+old mode 100644
+new mode 100755
+`
+)
+
+func TestSuggestType(t *testing.T) {
+	diffs := map[string]string{
+		"main.go":       reindented,
+		"util.go":       reindented,
+		"edited.go":     edited,
+		"logo.png":      binary,
+		"build.sh":      modeOnly,
+		"broken.go":     "",
+		"docs/guide.md": "",
+	}
+	load := func(c model.GitChange) (string, error) {
+		if c.Path == "broken.go" {
+			return "", errors.New("no diff")
+		}
+		return diffs[c.Path], nil
+	}
+
+	tests := []struct {
+		name       string
+		items      []model.GitChange
+		rules      []config.TypeRule
+		loadDiff   DiffLoader
+		wantType   string
+		wantReason string
+	}{
+		{name: "nothing", wantType: "", wantReason: ""},
+		{
+			name:       "tests",
+			items:      []model.GitChange{modified("pkg/a_test.go"), modified("pkg/testdata/in.txt")},
+			wantType:   "test",
+			wantReason: "only test files changed",
+		},
+		{
+			name:       "docs",
+			items:      []model.GitChange{modified("README.md"), modified("docs/guide.md")},
+			wantType:   "docs",
+			wantReason: "only documentation changed",
+		},
+		{
+			name:       "ci",
+			items:      []model.GitChange{modified(".github/workflows/test.yml")},
+			wantType:   "ci",
+			wantReason: "only CI configuration changed",
+		},
+		{
+			name:       "build",
+			items:      []model.GitChange{modified("go.mod"), modified("go.sum")},
+			wantType:   "build",
+			wantReason: "only module files changed",
+		},
+		{
+			name:  "mixed paths",
+			items: []model.GitChange{modified("README.md"), modified("edited.go")},
+		},
+		{
+			name:       "configured rule first",
+			items:      []model.GitChange{modified("deploy/app.yaml")},
+			rules:      []config.TypeRule{{Type: "chore", Paths: []string{"deploy/**"}, Reason: "only deployment changed"}},
+			wantType:   "chore",
+			wantReason: "only deployment changed",
+		},
+		{
+			name:       "configured rule without a reason",
+			items:      []model.GitChange{modified("deploy/app.yaml")},
+			rules:      []config.TypeRule{{Type: "chore", Paths: []string{"deploy/**", "*.tf"}}},
+			wantType:   "chore",
+			wantReason: "the changed paths match deploy/**, *.tf",
+		},
+		{
+			name:       "configured rule replaces the built-in one",
+			items:      []model.GitChange{modified("docs/guide.md")},
+			rules:      []config.TypeRule{{Type: "docs", Paths: []string{"*.txt"}}},
+			loadDiff:   load,
+			wantType:   "",
+			wantReason: "",
+		},
+		{
+			name:       "whitespace only",
+			items:      []model.GitChange{modified("main.go"), modified("util.go")},
+			loadDiff:   load,
+			wantType:   "style",
+			wantReason: "only whitespace changed",
+		},
+		{
+			name:       "whitespace of a selected patch",
+			items:      []model.GitChange{{Index: ' ', Working: 'M', Path: "edited.go", Patch: reindented}},
+			loadDiff:   load,
+			wantType:   "style",
+			wantReason: "only whitespace changed",
+		},
+		{
+			name:     "whitespace and an edit",
+			items:    []model.GitChange{modified("main.go"), modified("edited.go")},
+			loadDiff: load,
+		},
+		{
+			name:  "whitespace without a loader",
+			items: []model.GitChange{modified("main.go")},
+		},
+		{
+			name:     "binary",
+			items:    []model.GitChange{modified("logo.png")},
+			loadDiff: load,
+		},
+		{
+			name:     "mode only",
+			items:    []model.GitChange{modified("build.sh")},
+			loadDiff: load,
+		},
+		{
+			name:     "unreadable diff",
+			items:    []model.GitChange{modified("broken.go")},
+			loadDiff: load,
+		},
+		{
+			name:     "added file",
+			items:    []model.GitChange{{Index: 'A', Working: ' ', Path: "main.go"}},
+			loadDiff: load,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotReason := SuggestType(tt.items, tt.rules, tt.loadDiff)
+			if gotType != tt.wantType || gotReason != tt.wantReason {
+				t.Errorf("SuggestType = %q, %q; want %q, %q", gotType, gotReason, tt.wantType, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gcm/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the project configuration file, looked up from the working
+// directory up to the repository root.
+const FileName = ".gcm.yaml"
+
+// Title casing rules for the first letter of a commit title
+const (
+	CaseLower = "lower"
+	CaseUpper = "upper"
+	CaseAny   = "any"
+)
+
+// Config holds every rule gcm enforces. Keys missing from the files keep
+// their defaults.
+type Config struct {
+	Types             []model.CommitType `yaml:"types"`
+	Scopes            []string           `yaml:"scopes"`
+	ProtectedBranches []string           `yaml:"protected_branches"` // glob patterns, e.g. "release/*"
+	Title             TitleRules         `yaml:"title"`
+	Branch            BranchRules        `yaml:"branch"`
+	Remote            string             `yaml:"remote"`
+	Backend           string             `yaml:"backend"`
+
+	// Rules overrides lint rules by id, e.g. "body-max-line-length"
+	Rules map[string]RuleSetting `yaml:"rules"`
+
+	// TypeRules suggest a commit type when every selected path matches one
+	// of their globs; a rule replaces the built-in one for the same type
+	TypeRules []TypeRule `yaml:"type_rules"`
+
+	// Draft configures commit message drafting by a language model
+	Draft DraftConfig `yaml:"draft"`
+
+	// LargeFileBytes marks files at least this big in the file selector;
+	// 0 turns the marker off
+	LargeFileBytes int64 `yaml:"large_file_bytes"`
+}
+
+type TitleRules struct {
+	MinLength   int    `yaml:"min_length"`
+	MaxLength   int    `yaml:"max_length"`
+	WarnLength  int    `yaml:"warn_length"` // longer titles are allowed with a warning
+	Case        string `yaml:"case"`        // "lower", "upper" or "any"
+	AllowPeriod bool   `yaml:"allow_period"`
+}
+
+type BranchRules struct {
+	MaxLength int `yaml:"max_length"`
+}
+
+type TypeRule struct {
+	Type   string   `yaml:"type"`
+	Paths  []string `yaml:"paths"`  // globs such as "*.md" or "deploy/**"
+	Reason string   `yaml:"reason"` // shown as a hint next to the suggestion
+}
+
+// DraftConfig points at an OpenAI-compatible chat completions server.
+// Drafting is off while Endpoint is empty. Endpoint and APIKeyEnv are only
+// read from the user configuration.
+type DraftConfig struct {
+	Endpoint     string        `yaml:"endpoint"` // base URL, e.g. http://localhost:11434/v1
+	Model        string        `yaml:"model"`
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxDiffBytes int           `yaml:"max_diff_bytes"` // longer diffs are truncated
+	Ignore       []string      `yaml:"ignore"`         // globs of paths whose content is never sent
+	APIKeyEnv    string        `yaml:"api_key_env"`    // environment variable holding a bearer token
+}
+
+// Enabled reports whether drafting is configured.
+func (d DraftConfig) Enabled() bool {
+	return d.Endpoint != ""
+}
+
+// Default returns the rules gcm applies without any configuration file.
+func Default() Config {
+	return Config{
+		Types:             slices.Clone(model.CommitTypes),
+		ProtectedBranches: []string{"main", "master"},
+		Title: TitleRules{
+			MinLength:  10,
+			MaxLength:  72,
+			WarnLength: 50,
+			Case:       CaseLower,
+		},
+		Branch: BranchRules{
+			MaxLength: 50,
+		},
+		Remote: "origin",
+		Draft: DraftConfig{
+			Timeout:      30 * time.Second,
+			MaxDiffBytes: 16000,
+			Ignore:       []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "go.sum", "*.lock", "secrets/**"},
+		},
+		LargeFileBytes: 1 << 20,
+	}
+}
+
+// UserPath returns the user-level configuration file,
+// $XDG_CONFIG_HOME/gcm/config.yaml or ~/.config/gcm/config.yaml.
+func UserPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gcm", "config.yaml"), nil
+}
+
+// FindProjectFile looks for FileName in dir and its parents, stopping at
+// the repository root. It returns "" if there is none.
+func FindProjectFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		file := filepath.Join(dir, FileName)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the user configuration and then the project configuration
+// found from dir, each overriding the keys it sets, on top of Default.
+func Load(dir string) (Config, error) {
+	cfg := Default()
+
+	userFile, err := UserPath()
+	if err == nil {
+		if err := readFile(userFile, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	projectFile, err := FindProjectFile(dir)
+	if err != nil {
+		return cfg, err
+	}
+	if projectFile != "" {
+		// A cloned repository must not decide where diffs and which
+		// environment secrets are sent, and can only add to the paths
+		// kept out of them
+		endpoint, keyEnv, ignore := cfg.Draft.Endpoint, cfg.Draft.APIKeyEnv, cfg.Draft.Ignore
+		if err := readFile(projectFile, &cfg); err != nil {
+			return cfg, err
+		}
+		cfg.Draft.Endpoint, cfg.Draft.APIKeyEnv = endpoint, keyEnv
+		for _, pattern := range cfg.Draft.Ignore {
+			if !slices.Contains(ignore, pattern) {
+				ignore = append(ignore, pattern)
+			}
+		}
+		cfg.Draft.Ignore = ignore
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func readFile(file string, cfg *Config) error {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return nil
+}
+
+// Validate rejects configurations no title or branch could satisfy.
+func (c Config) Validate() error {
+	if len(c.Types) == 0 {
+		return fmt.Errorf("config: at least one commit type is required")
+	}
+	for _, t := range c.Types {
+		if t.Key == "" {
+			return fmt.Errorf("config: commit types need a key")
+		}
+	}
+
+	for _, pattern := range c.ProtectedBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("config: bad protected branch pattern %q", pattern)
+		}
+	}
+
+	for _, rule := range c.TypeRules {
+		if rule.Type == "" || len(rule.Paths) == 0 {
+			return fmt.Errorf("config: type_rules need a type and paths")
+		}
+		for _, pattern := range rule.Paths {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("config: bad type rule pattern %q", pattern)
+			}
+		}
+	}
+
+	if c.Title.MinLength < 1 || c.Title.MaxLength < c.Title.MinLength {
+		return fmt.Errorf("config: title lengths need 1 <= min_length <= max_length")
+	}
+
+	switch c.Title.Case {
+	case CaseLower, CaseUpper, CaseAny:
+	default:
+		return fmt.Errorf("config: title case must be %q, %q or %q", CaseLower, CaseUpper, CaseAny)
+	}
+
+	if c.Branch.MaxLength < 1 {
+		return fmt.Errorf("config: branch max_length must be positive")
+	}
+
+	if c.Remote == "" {
+		return fmt.Errorf("config: remote cannot be empty")
+	}
+
+	if c.LargeFileBytes < 0 {
+		return fmt.Errorf("config: large_file_bytes cannot be negative")
+	}
+
+	if c.Draft.Enabled() {
+		if !strings.HasPrefix(c.Draft.Endpoint, "http://") && !strings.HasPrefix(c.Draft.Endpoint, "https://") {
+			return fmt.Errorf("config: draft endpoint must be an http or https URL")
+		}
+		if c.Draft.Model == "" {
+			return fmt.Errorf("config: draft needs a model")
+		}
+		if c.Draft.Timeout <= 0 || c.Draft.MaxDiffBytes <= 0 {
+			return fmt.Errorf("config: draft timeout and max_diff_bytes must be positive")
+		}
+		for _, pattern := range c.Draft.Ignore {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("config: bad draft ignore pattern %q", pattern)
+			}
+		}
+	}
+
+	return nil
+}
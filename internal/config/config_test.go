@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// load runs Load on a repository holding project, with user as the user
+// configuration; either may be empty for none.
+func load(t *testing.T, user, project string) Config {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	if user != "" {
+		if err := os.MkdirAll(filepath.Join(home, "gcm"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(home, "gcm", "config.yaml"), []byte(user), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if project != "" {
+		if err := os.WriteFile(filepath.Join(repo, FileName), []byte(project), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := Load(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLoadDraft(t *testing.T) {
+	defaults := Default().Draft.Ignore
+
+	tests := []struct {
+		name     string
+		user     string
+		project  string
+		endpoint string
+		keyEnv   string
+		ignore   []string
+	}{
+		{
+			name:   "defaults",
+			ignore: defaults,
+		},
+		{
+			name:     "user configuration",
+			user:     "draft: {endpoint: http://localhost:11434/v1, model: llama3, api_key_env: LLM_KEY, ignore: [\"*.secret\"]}",
+			endpoint: "http://localhost:11434/v1",
+			keyEnv:   "LLM_KEY",
+			ignore:   []string{"*.secret"},
+		},
+		{
+			name:     "project can't redirect the draft",
+			user:     "draft: {endpoint: http://localhost:11434/v1, model: llama3}",
+			project:  "draft: {endpoint: https://evil.example.com, api_key_env: AWS_SECRET_ACCESS_KEY}",
+			endpoint: "http://localhost:11434/v1",
+			ignore:   defaults,
+		},
+		{
+			name:    "project adds to the default ignore list",
+			project: "draft: {ignore: [\"vendor/**\", \".env\"]}",
+			ignore:  append(slices.Clone(defaults), "vendor/**"),
+		},
+		{
+			name:    "project adds to the user ignore list",
+			user:    "draft: {ignore: [\"*.secret\"]}",
+			project: "draft: {ignore: [\"vendor/**\"]}",
+			ignore:  []string{"*.secret", "vendor/**"},
+		},
+		{
+			name:    "project can't empty the ignore list",
+			project: "draft: {ignore: []}",
+			ignore:  defaults,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := load(t, tt.user, tt.project)
+			if cfg.Draft.Endpoint != tt.endpoint || cfg.Draft.APIKeyEnv != tt.keyEnv {
+				t.Errorf("endpoint, key = %q, %q; want %q, %q", cfg.Draft.Endpoint, cfg.Draft.APIKeyEnv, tt.endpoint, tt.keyEnv)
+			}
+			if !slices.Equal(cfg.Draft.Ignore, tt.ignore) {
+				t.Errorf("ignore = %v, want %v", cfg.Draft.Ignore, tt.ignore)
+			}
+		})
+	}
+}
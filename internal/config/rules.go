@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule levels, as in commitlint
+const (
+	LevelOff     = 0
+	LevelWarning = 1
+	LevelError   = 2
+)
+
+// RuleSetting configures one lint rule with commitlint's
+// [level, applicable, value] tuple, e.g. [2, always, 72] or [1, never].
+type RuleSetting struct {
+	Level int
+	When  string // "always" or "never"
+	Value any
+}
+
+func (r *RuleSetting) UnmarshalYAML(node *yaml.Node) error {
+	var tuple []any
+	if err := node.Decode(&tuple); err != nil {
+		return err
+	}
+	if len(tuple) == 0 || len(tuple) > 3 {
+		return fmt.Errorf("line %d: a rule is [level, always|never, value]", node.Line)
+	}
+
+	level, ok := tuple[0].(int)
+	if !ok || level < LevelOff || level > LevelError {
+		return fmt.Errorf("line %d: rule level must be 0, 1 or 2", node.Line)
+	}
+
+	*r = RuleSetting{Level: level, When: "always"}
+	if len(tuple) > 1 {
+		when, ok := tuple[1].(string)
+		if !ok || (when != "always" && when != "never") {
+			return fmt.Errorf("line %d: rule applicability must be 'always' or 'never'", node.Line)
+		}
+		r.When = when
+	}
+	if len(tuple) > 2 {
+		r.Value = tuple[2]
+	}
+	return nil
+}
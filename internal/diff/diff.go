@@ -0,0 +1,275 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Line struct {
+	Kind      byte // ' ', '+' or '-'
+	Text      string
+	NoNewline bool // followed by "\ No newline at end of file"
+}
+
+func (l Line) IsChange() bool {
+	return l.Kind == '+' || l.Kind == '-'
+}
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string
+	Lines    []Line
+}
+
+func (h Hunk) Header() string {
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// File is the diff of a single path as printed by `git diff -- <path>`.
+type File struct {
+	Header []string
+	Hunks  []Hunk
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+func Parse(text string) File {
+	var f File
+	var hunk *Hunk
+
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			if hunk != nil {
+				f.Hunks = append(f.Hunks, *hunk)
+			}
+			hunk = &Hunk{
+				OldStart: atoi(m[1], 0),
+				OldLines: atoi(m[2], 1),
+				NewStart: atoi(m[3], 0),
+				NewLines: atoi(m[4], 1),
+				Section:  m[5],
+			}
+			continue
+		}
+
+		if hunk == nil {
+			if line != "" {
+				f.Header = append(f.Header, line)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, `\`) {
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].NoNewline = true
+			}
+			continue
+		}
+
+		if line == "" {
+			// Some tools strip the trailing space of empty context lines
+			hunk.Lines = append(hunk.Lines, Line{Kind: ' '})
+			continue
+		}
+		hunk.Lines = append(hunk.Lines, Line{Kind: line[0], Text: line[1:]})
+	}
+
+	if hunk != nil {
+		f.Hunks = append(f.Hunks, *hunk)
+	}
+
+	return f
+}
+
+func selectedAfter(h Hunk, hunk, line int, selected func(hunk, line int) bool) bool {
+	for li := line + 1; li < len(h.Lines); li++ {
+		if h.Lines[li].Kind == '+' && selected(hunk, li) {
+			return true
+		}
+	}
+	return false
+}
+
+func atoi(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// Patch builds a patch containing only the change lines for which selected
+// returns true, in the format `git apply --cached` expects. Unselected
+// additions are dropped and unselected deletions become context, the same
+// way `git add -p` edits a hunk. It returns "" when nothing is selected.
+func (f File) Patch(selected func(hunk, line int) bool) string {
+	var b strings.Builder
+	offset := 0
+
+	for hi, h := range f.Hunks {
+		var lines []Line
+		changed := false
+		oldLines, newLines := 0, 0
+
+		for li, l := range h.Lines {
+			switch {
+			case l.Kind == ' ':
+				lines = append(lines, l)
+				oldLines++
+				newLines++
+			case selected(hi, li):
+				lines = append(lines, l)
+				changed = true
+				if l.Kind == '-' {
+					oldLines++
+				} else {
+					newLines++
+				}
+			case l.Kind == '-' && l.NoNewline && selectedAfter(h, hi, li, selected):
+				// The old last line stays, but content is appended after
+				// it, so it has to be re-added with a trailing newline.
+				lines = append(lines, l, Line{Kind: '+', Text: l.Text})
+				oldLines++
+				newLines++
+			case l.Kind == '-':
+				l.Kind = ' '
+				lines = append(lines, l)
+				oldLines++
+				newLines++
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		out := Hunk{
+			OldStart: h.OldStart,
+			OldLines: oldLines,
+			NewStart: h.OldStart + offset,
+			NewLines: newLines,
+			Section:  h.Section,
+		}
+		if oldLines == 0 {
+			out.NewStart++
+		}
+		if newLines == 0 && out.NewStart > 0 {
+			out.NewStart--
+		}
+		offset += newLines - oldLines
+
+		b.WriteString(out.Header() + "\n")
+		for _, l := range lines {
+			b.WriteByte(l.Kind)
+			b.WriteString(l.Text + "\n")
+			if l.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	if b.Len() == 0 {
+		return ""
+	}
+
+	return strings.Join(f.Header, "\n") + "\n" + b.String()
+}
+
+// Apply applies the hunks of f to content, which must match the old side
+// of every hunk exactly.
+func (f File) Apply(content string) (string, error) {
+	old := strings.SplitAfter(content, "\n")
+	if old[len(old)-1] == "" {
+		old = old[:len(old)-1]
+	}
+
+	var out []string
+	pos := 0
+
+	for _, h := range f.Hunks {
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart
+		}
+		if start < pos || start > len(old) {
+			return "", fmt.Errorf("hunk %s does not apply", h.Header())
+		}
+
+		out = append(out, old[pos:start]...)
+		pos = start
+
+		for _, l := range h.Lines {
+			text := l.Text
+			if !l.NoNewline {
+				text += "\n"
+			}
+
+			if l.Kind == '+' {
+				out = append(out, text)
+				continue
+			}
+			if pos >= len(old) || old[pos] != text {
+				return "", fmt.Errorf("hunk %s does not apply", h.Header())
+			}
+			if l.Kind == ' ' {
+				out = append(out, text)
+			}
+			pos++
+		}
+	}
+
+	out = append(out, old[pos:]...)
+	return strings.Join(out, ""), nil
+}
+
+// Path returns the path the diff applies to, taken from its "+++" header
+// line, or from "---" for deletions.
+func (f File) Path() string {
+	var from string
+	for _, line := range f.Header {
+		if path, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			return path
+		}
+		if path, ok := strings.CutPrefix(line, "--- a/"); ok {
+			from = path
+		}
+	}
+	return from
+}
+
+// Binary reports whether git printed the file as a binary change.
+func (f File) Binary() bool {
+	for _, line := range f.Header {
+		if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+			return true
+		}
+	}
+	return false
+}
+
+// Stat counts the added and removed lines of the diff.
+func (f File) Stat() (added, removed int) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	return added, removed
+}
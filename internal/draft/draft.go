@@ -0,0 +1,207 @@
+// Package draft asks a language model behind an OpenAI-compatible chat
+// completions endpoint, such as a local Ollama server, for a commit
+// message draft.
+package draft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+// truncated marks a diff cut at the size limit.
+const truncated = "\n[diff truncated]\n"
+
+// Client drafts commit messages. The zero HTTP client means
+// http.DefaultClient.
+type Client struct {
+	Config config.DraftConfig
+	Title  config.TitleRules
+	APIKey string
+	HTTP   *http.Client
+}
+
+// New returns a client for cfg, reading the API key from the environment
+// variable it names.
+func New(cfg config.Config) *Client {
+	c := &Client{Config: cfg.Draft, Title: cfg.Title}
+	if cfg.Draft.APIKeyEnv != "" {
+		c.APIKey = os.Getenv(cfg.Draft.APIKeyEnv)
+	}
+	return c
+}
+
+// Diff joins the diffs of items for the prompt. Paths matching the ignore
+// globs are left out entirely, and the result is cut at maxBytes.
+func Diff(items []model.GitChange, loadDiff changes.DiffLoader, ignore []string, maxBytes int) (string, error) {
+	var b strings.Builder
+	for _, it := range items {
+		if ignored(it, ignore) {
+			continue
+		}
+
+		text := it.Patch
+		if text == "" {
+			var err error
+			if text, err = loadDiff(it); err != nil {
+				return "", fmt.Errorf("diff of %s: %w", it.Path, err)
+			}
+		}
+		b.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			b.WriteString("\n")
+		}
+
+		if b.Len() > maxBytes {
+			break
+		}
+	}
+
+	diff := b.String()
+	if len(diff) > maxBytes {
+		diff = strings.ToValidUTF8(diff[:maxBytes], "") + truncated
+	}
+	return diff, nil
+}
+
+func ignored(it model.GitChange, ignore []string) bool {
+	for _, pattern := range ignore {
+		if changes.MatchGlob(pattern, it.Path) || (it.OrigPath != "" && changes.MatchGlob(pattern, it.OrigPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type request struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream"`
+}
+
+type response struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Draft asks the model for the title and description of a commit of the
+// given type and scope. The result still has to pass the lint rules.
+func (c *Client) Draft(ctx context.Context, info model.CommitInfo, diff string) (model.CommitInfo, error) {
+	if strings.TrimSpace(diff) == "" {
+		return info, errors.New("nothing to describe: every selected path is ignored")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Config.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(request{
+		Model: c.Config.Model,
+		Messages: []message{
+			{Role: "system", Content: c.systemPrompt()},
+			{Role: "user", Content: fmt.Sprintf("Commit header: %s: <subject>\n\nDiff:\n%s", info.Prefix(), diff)},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return info, err
+	}
+
+	endpoint := strings.TrimSuffix(c.Config.Endpoint, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return info, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return info, fmt.Errorf("no answer within %s", c.Config.Timeout)
+		}
+		return info, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return info, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var parsed response
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return info, fmt.Errorf("parsing response: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return info, errors.New("the response has no choices")
+	}
+
+	return apply(info, parsed.Choices[0].Message.Content)
+}
+
+func (c *Client) systemPrompt() string {
+	casing := "lowercase"
+	switch c.Title.Case {
+	case config.CaseUpper:
+		casing = "capitalized"
+	case config.CaseAny:
+		casing = "plain"
+	}
+
+	return fmt.Sprintf("You write git commit messages following Conventional Commits. "+
+		"Reply with the subject only, without the type prefix: one %s line in the imperative mood, "+
+		"at most %d characters, with no period at the end. "+
+		"Then a blank line and a short body explaining why the change was made, wrapped at 72 characters. "+
+		"No markdown, no quotes, no trailers.", casing, c.Title.MaxLength)
+}
+
+// apply fills info's title and description from the model's reply,
+// dropping a conventional header prefix and code fences if the model
+// added them anyway. Everything after the subject is the description,
+// "Token: value" lines included, so that nothing the model wrote is lost
+// or turned into trailers unseen.
+func apply(info model.CommitInfo, reply string) (model.CommitInfo, error) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(reply), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "```") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+
+	header, body, _ := strings.Cut(strings.Trim(strings.Join(lines, "\n"), "\n"), "\n")
+	title := strings.Trim(model.ParseMessage(strings.Trim(header, "\"'` ")).Title, "\"'` ")
+	if title == "" {
+		return info, errors.New("the reply has no subject")
+	}
+
+	info.Title = title
+	info.Description = strings.Trim(body, "\n")
+	return info, nil
+}
@@ -0,0 +1,320 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gcm/internal/diff"
+	"gcm/internal/model"
+)
+
+// FakeRepository is an in-memory Repository for exercising the commit
+// workflow without a git binary or a repository on disk. Changes hold one
+// entry per path, as `git status` reports them.
+type FakeRepository struct {
+	Branch         string
+	Branches       []string
+	Changes        []model.GitChange
+	Diffs          map[string]string
+	RemoteBranches map[string]bool
+	Config         map[string][]string
+	KnownAuthors   []string
+	Hooks          string // directory returned by HooksDir
+	Dir            string // top-level directory returned by Root
+	TagList        []Tag
+	Sizes          map[string]int64 // file sizes reported by DiffStats
+
+	Commits []FakeCommit
+	Patches []string
+	Pushed  []string
+	Logged  []string // ranges passed to Log
+
+	// Errors makes the named method (e.g. "Commit") fail.
+	Errors map[string]error
+}
+
+type FakeCommit struct {
+	Branch  string
+	Message string
+	Paths   []string
+}
+
+func NewFakeRepository(branch string, changes ...model.GitChange) *FakeRepository {
+	return &FakeRepository{
+		Branch:         branch,
+		Branches:       []string{branch},
+		Changes:        changes,
+		Diffs:          make(map[string]string),
+		RemoteBranches: make(map[string]bool),
+		Config:         make(map[string][]string),
+		Errors:         make(map[string]error),
+	}
+}
+
+func (f *FakeRepository) fail(method string) error {
+	return f.Errors[method]
+}
+
+func (f *FakeRepository) Status() ([]model.GitChange, error) {
+	if err := f.fail("Status"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.Changes), nil
+}
+
+func (f *FakeRepository) CurrentBranch() (string, error) {
+	if err := f.fail("CurrentBranch"); err != nil {
+		return "", err
+	}
+	return f.Branch, nil
+}
+
+func (f *FakeRepository) CreateBranch(name string) error {
+	if err := f.fail("CreateBranch"); err != nil {
+		return err
+	}
+	if slices.Contains(f.Branches, name) {
+		return fmt.Errorf("a branch named '%s' already exists", name)
+	}
+	f.Branches = append(f.Branches, name)
+	f.Branch = name
+	return nil
+}
+
+func (f *FakeRepository) CheckoutBranch(name string) error {
+	if err := f.fail("CheckoutBranch"); err != nil {
+		return err
+	}
+	if !slices.Contains(f.Branches, name) {
+		return fmt.Errorf("pathspec '%s' did not match any branch", name)
+	}
+	f.Branch = name
+	return nil
+}
+
+func (f *FakeRepository) Add(paths []string) error {
+	if err := f.fail("Add"); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		i := f.find(path)
+		if i < 0 && f.inUntrackedDir(path) {
+			// A file of an untracked directory, which Status lists whole
+			f.Changes = append(f.Changes, model.GitChange{Index: 'A', Working: ' ', Path: path})
+			continue
+		}
+		if i < 0 {
+			return fmt.Errorf("pathspec '%s' did not match any files", path)
+		}
+		c := &f.Changes[i]
+		switch {
+		case c.Working == '?':
+			c.Index = 'A'
+		case c.Index == ' ' || c.Index == '?':
+			c.Index = c.Working
+		}
+		c.Working = ' '
+	}
+	return nil
+}
+
+func (f *FakeRepository) Unstage(paths []string) error {
+	if err := f.fail("Unstage"); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		i := f.find(path)
+		if i < 0 || !f.Changes[i].IsStaged() {
+			continue
+		}
+		c := &f.Changes[i]
+		if c.Index == 'A' {
+			c.Working = '?'
+			c.Index = '?'
+			continue
+		}
+		if c.Working == ' ' {
+			c.Working = c.Index
+		}
+		c.Index = ' '
+	}
+	return nil
+}
+
+// ApplyCached records the patch and marks its path as partially staged.
+func (f *FakeRepository) ApplyCached(patch string) error {
+	if err := f.fail("ApplyCached"); err != nil {
+		return err
+	}
+	path := diff.Parse(patch).Path()
+	i := f.find(path)
+	if i < 0 {
+		return fmt.Errorf("%s: does not exist in index", path)
+	}
+	f.Changes[i].Index = 'M'
+	f.Patches = append(f.Patches, patch)
+	return nil
+}
+
+func (f *FakeRepository) Diff(change model.GitChange, staged bool) (string, error) {
+	if err := f.fail("Diff"); err != nil {
+		return "", err
+	}
+	return f.Diffs[change.Path], nil
+}
+
+func (f *FakeRepository) DiffStats(changes []model.GitChange) ([]model.DiffStat, error) {
+	if err := f.fail("DiffStats"); err != nil {
+		return nil, err
+	}
+	stats, err := diffStats(f, changes)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range changes {
+		stats[i].Size = f.Sizes[c.Path]
+	}
+	return stats, nil
+}
+
+// Commit records every staged path and clears it from the index.
+func (f *FakeRepository) Commit(msg string) error {
+	if err := f.fail("Commit"); err != nil {
+		return err
+	}
+
+	commit := FakeCommit{Branch: f.Branch, Message: msg}
+	var remaining []model.GitChange
+	for _, c := range f.Changes {
+		if c.IsStaged() {
+			commit.Paths = append(commit.Paths, c.Path)
+			c.Index = ' '
+		}
+		if c.Index != ' ' || c.Working != ' ' {
+			remaining = append(remaining, c)
+		}
+	}
+
+	if len(commit.Paths) == 0 {
+		return fmt.Errorf("nothing added to commit")
+	}
+
+	f.Changes = remaining
+	f.Commits = append(f.Commits, commit)
+	return nil
+}
+
+func (f *FakeRepository) HasRemoteBranch(branch string) (bool, error) {
+	if err := f.fail("HasRemoteBranch"); err != nil {
+		return false, err
+	}
+	return f.RemoteBranches[branch], nil
+}
+
+func (f *FakeRepository) Push(branch string, setUpstream bool) error {
+	if err := f.fail("Push"); err != nil {
+		return err
+	}
+	if !setUpstream && !f.RemoteBranches[branch] {
+		return fmt.Errorf("the current branch %s has no upstream branch", branch)
+	}
+	f.RemoteBranches[branch] = true
+	f.Pushed = append(f.Pushed, branch)
+	return nil
+}
+
+func (f *FakeRepository) ConfigValues(key string) ([]string, error) {
+	if err := f.fail("ConfigValues"); err != nil {
+		return nil, err
+	}
+	return f.Config[key], nil
+}
+
+func (f *FakeRepository) Authors() ([]string, error) {
+	if err := f.fail("Authors"); err != nil {
+		return nil, err
+	}
+	return f.KnownAuthors, nil
+}
+
+// Log records the range and lists every recorded commit, newest first.
+func (f *FakeRepository) Log(revRange string) ([]Commit, error) {
+	if err := f.fail("Log"); err != nil {
+		return nil, err
+	}
+	f.Logged = append(f.Logged, revRange)
+
+	var commits []Commit
+	for i := len(f.Commits) - 1; i >= 0; i-- {
+		commits = append(commits, Commit{Hash: fmt.Sprintf("%040x", i+1), Message: f.Commits[i].Message})
+	}
+	return commits, nil
+}
+
+func (f *FakeRepository) ChangedPaths(limit int) ([][]string, error) {
+	if err := f.fail("ChangedPaths"); err != nil {
+		return nil, err
+	}
+
+	var history [][]string
+	for i := len(f.Commits) - 1; i >= 0 && len(history) < limit; i-- {
+		if len(f.Commits[i].Paths) > 0 {
+			history = append(history, f.Commits[i].Paths)
+		}
+	}
+	return history, nil
+}
+
+func (f *FakeRepository) HooksDir() (string, error) {
+	if err := f.fail("HooksDir"); err != nil {
+		return "", err
+	}
+	return f.Hooks, nil
+}
+
+func (f *FakeRepository) Root() (string, error) {
+	if err := f.fail("Root"); err != nil {
+		return "", err
+	}
+	return f.Dir, nil
+}
+
+func (f *FakeRepository) Tags() ([]Tag, error) {
+	if err := f.fail("Tags"); err != nil {
+		return nil, err
+	}
+	return f.TagList, nil
+}
+
+// CreateTag tags the last recorded commit.
+func (f *FakeRepository) CreateTag(name, message string, sign bool) error {
+	if err := f.fail("CreateTag"); err != nil {
+		return err
+	}
+	if slices.ContainsFunc(f.TagList, func(t Tag) bool { return t.Name == name }) {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+	f.TagList = append(f.TagList, Tag{Name: name, Commit: fmt.Sprintf("%040x", len(f.Commits))})
+	return nil
+}
+
+func (f *FakeRepository) PushTag(name string) error {
+	if err := f.fail("PushTag"); err != nil {
+		return err
+	}
+	f.Pushed = append(f.Pushed, "refs/tags/"+name)
+	return nil
+}
+
+func (f *FakeRepository) find(path string) int {
+	return slices.IndexFunc(f.Changes, func(c model.GitChange) bool {
+		return c.Path == path
+	})
+}
+
+func (f *FakeRepository) inUntrackedDir(path string) bool {
+	return slices.ContainsFunc(f.Changes, func(c model.GitChange) bool {
+		return c.Index == '?' && strings.HasSuffix(c.Path, "/") && strings.HasPrefix(path, c.Path)
+	})
+}
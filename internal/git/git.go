@@ -2,95 +2,384 @@ package git
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"gcm/internal/changes"
+	"gcm/internal/model"
 )
 
-func CheckChangedFiles() (string, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
-	out, err := cmd.Output()
+// ExecRepository implements Repository by running the git binary.
+type ExecRepository struct {
+	Dir    string
+	Remote string
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Terminal, if set, is released while git runs the commands that may
+	// prompt, such as for credentials or a GPG passphrase.
+	Terminal Terminal
+}
+
+// Terminal is a full-screen UI holding the terminal.
+type Terminal interface {
+	ReleaseTerminal() error
+	RestoreTerminal() error
+}
+
+func NewExecRepository(dir string) *ExecRepository {
+	return &ExecRepository{
+		Dir:    dir,
+		Remote: DefaultRemote,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+func (r *ExecRepository) command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	return cmd
+}
+
+// run executes a git command with its output wired to the repository's
+// writers.
+func (r *ExecRepository) run(args ...string) error {
+	cmd := r.command(args...)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	return cmd.Run()
+}
+
+// runInteractive runs a git command that may prompt, handing it stdin and
+// the terminal.
+func (r *ExecRepository) runInteractive(args ...string) error {
+	if r.Terminal != nil {
+		if err := r.Terminal.ReleaseTerminal(); err != nil {
+			return err
+		}
+		defer r.Terminal.RestoreTerminal()
+	}
+
+	cmd := r.command(args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	return cmd.Run()
+}
+
+func (r *ExecRepository) output(args ...string) (string, error) {
+	out, err := r.command(args...).Output()
 	if err != nil {
 		return "", err
 	}
 	return string(out), nil
 }
 
-func GetCurrentBranch() (string, error) {
-	cmd := exec.Command("git", "branch", "--show-current")
-	out, err := cmd.Output()
+func (r *ExecRepository) Status() ([]model.GitChange, error) {
+	out, err := r.output("status", "--porcelain=v2", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return changes.ParseChangedFiles(out), nil
+}
+
+func (r *ExecRepository) CurrentBranch() (string, error) {
+	out, err := r.output("branch", "--show-current")
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(string(out)), nil
+	return strings.TrimSpace(out), nil
 }
 
-func IsMainBranch(branch string) bool {
-	return branch == "main" || branch == "master"
+func (r *ExecRepository) CreateBranch(branchName string) error {
+	return r.run("checkout", "-b", branchName)
 }
 
-func CreateBranch(branchName string) error {
-	cmd := exec.Command("git", "checkout", "-b", branchName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+func (r *ExecRepository) CheckoutBranch(branchName string) error {
+	return r.run("checkout", branchName)
 }
 
-func CheckoutBranch(branchName string) error {
-	cmd := exec.Command("git", "checkout", branchName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+func (r *ExecRepository) Add(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return r.run(append([]string{"add", "--"}, paths...)...)
 }
 
-func Add(paths []string) error {
+// Unstage resets the index entries of paths to HEAD, keeping the working
+// tree untouched.
+func (r *ExecRepository) Unstage(paths []string) error {
 	if len(paths) == 0 {
 		return nil
 	}
-	args := append([]string{"add"}, paths...)
-	cmd := exec.Command("git", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return r.run(append([]string{"reset", "-q", "--"}, paths...)...)
 }
 
-func Commit(msg string) error {
-	cmd := exec.Command("git", "commit", "-m", msg)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+func (r *ExecRepository) ApplyCached(patch string) error {
+	cmd := r.command("apply", "--cached", "-")
+	cmd.Stdin = strings.NewReader(patch)
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
 	return cmd.Run()
 }
 
-func CommitWithDescription(title, description string) error {
-	var msg string
-	if description != "" {
-		msg = fmt.Sprintf("%s\n\n%s", title, description)
-	} else {
-		msg = title
+// Diff returns the staged or unstaged diff of a change. Untracked paths are
+// rendered as an all-additions diff, and untracked directories as the list
+// of files inside them.
+func (r *ExecRepository) Diff(change model.GitChange, staged bool) (string, error) {
+	if change.Index == '?' {
+		return r.diffUntracked(change.Path)
 	}
-	cmd := exec.Command("git", "commit", "-m", msg)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	return r.output(append(args, "--", change.Path)...)
+}
+
+// DiffStats counts the lines of each change with `git diff --numstat`:
+// one run for the index, one for the working tree, and a diff for each
+// untracked file. Sizes come from the files below the top-level directory.
+func (r *ExecRepository) DiffStats(changes []model.GitChange) ([]model.DiffStat, error) {
+	root, err := r.Root()
+	if err != nil {
+		return nil, err
+	}
+	staged, err := r.numstat("--cached")
+	if err != nil {
+		return nil, err
+	}
+	unstaged, err := r.numstat()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]model.DiffStat, len(changes))
+	for i, c := range changes {
+		switch {
+		case c.Index == '?':
+			text, err := r.diffUntracked(c.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasSuffix(c.Path, "/") {
+				stats[i] = statOf(text)
+			}
+		case c.IsStaged():
+			stats[i] = staged[c.Path]
+		default:
+			stats[i] = unstaged[c.Path]
+		}
+	}
+	return stats, fileSizes(root, changes, stats)
+}
+
+// numstat parses `git diff --numstat -z` by path. Binary files show "-"
+// for both counts.
+func (r *ExecRepository) numstat(args ...string) (map[string]model.DiffStat, error) {
+	out, err := r.output(append([]string{"diff", "--numstat", "-z", "--no-ext-diff"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]model.DiffStat)
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		added, rest, ok := strings.Cut(fields[i], "\t")
+		if !ok {
+			continue
+		}
+		removed, path, _ := strings.Cut(rest, "\t")
+		if path == "" && i+2 < len(fields) {
+			// A rename: the source and destination follow
+			path = fields[i+2]
+			i += 2
+		}
+
+		var stat model.DiffStat
+		if added == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added, _ = strconv.Atoi(added)
+			stat.Removed, _ = strconv.Atoi(removed)
+		}
+		stats[path] = stat
+	}
+	return stats, nil
 }
 
-func HasRemoteBranch(branchName string) (bool, error) {
-	cmd := exec.Command("git", "rev-parse", "--verify", fmt.Sprintf("origin/%s", branchName))
-	err := cmd.Run()
+func (r *ExecRepository) diffUntracked(path string) (string, error) {
+	if strings.HasSuffix(path, "/") {
+		return r.output("ls-files", "--others", "--exclude-standard", "--", path)
+	}
+
+	out, err := r.command("diff", "--no-color", "--no-ext-diff", "--no-index", "--", "/dev/null", path).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// --no-index exits with 1 when the files differ
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (r *ExecRepository) Commit(msg string) error {
+	return r.runInteractive("commit", "-m", msg)
+}
+
+func (r *ExecRepository) HasRemoteBranch(branchName string) (bool, error) {
+	err := r.command("rev-parse", "--verify", r.Remote+"/"+branchName).Run()
 	if err != nil {
 		return false, nil
 	}
 	return true, nil
 }
 
-func Push(branchName string, setUpstream bool) error {
-	var cmd *exec.Cmd
+func (r *ExecRepository) Push(branchName string, setUpstream bool) error {
 	if setUpstream {
-		cmd = exec.Command("git", "push", "-u", "origin", branchName)
-	} else {
-		cmd = exec.Command("git", "push", "origin", branchName)
+		return r.runInteractive("push", "-u", r.Remote, branchName)
 	}
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return r.runInteractive("push", r.Remote, branchName)
+}
+
+// ConfigValues returns every value of a multi-valued git config key, or nil
+// when it is unset.
+func (r *ExecRepository) ConfigValues(key string) ([]string, error) {
+	out, err := r.output("config", "--get-all", key)
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(out, "\n"), "\n"), nil
+}
+
+// Authors lists the "Name <email>" identities of recent commit authors,
+// most frequent first.
+func (r *ExecRepository) Authors() ([]string, error) {
+	out, err := r.output("log", fmt.Sprintf("-n%d", authorHistory), "--format=%aN <%aE>")
+	if err != nil {
+		// No commits yet
+		return nil, nil
+	}
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return nil, nil
+	}
+	return rankAuthors(strings.Split(out, "\n")), nil
+}
+
+// Log lists the commits of a revision range such as "origin/main..HEAD",
+// newest first.
+func (r *ExecRepository) Log(revRange string) ([]Commit, error) {
+	cmd := r.command("log", "-z", "--format=%H%x1f%B", revRange, "--")
+	cmd.Stderr = r.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", revRange, err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x00") {
+		hash, msg, ok := strings.Cut(record, "\x1f")
+		if !ok {
+			continue
+		}
+		commits = append(commits, Commit{Hash: hash, Message: msg})
+	}
+	return commits, nil
+}
+
+// ChangedPaths lists the paths each of the latest limit commits touched,
+// newest first. Merge commits are left out.
+func (r *ExecRepository) ChangedPaths(limit int) ([][]string, error) {
+	out, err := r.output("log", fmt.Sprintf("-n%d", limit), "--no-merges", "--format=%x1e", "--name-only", "-z")
+	if err != nil {
+		// No commits yet
+		return nil, nil
+	}
+
+	var history [][]string
+	for _, record := range strings.Split(out, "\x1e") {
+		var paths []string
+		for _, p := range strings.Split(record, "\x00") {
+			if p = strings.TrimPrefix(p, "\n"); p != "" {
+				paths = append(paths, p)
+			}
+		}
+		if len(paths) > 0 {
+			history = append(history, paths)
+		}
+	}
+	return history, nil
+}
+
+// Root returns the top-level directory of the working tree.
+func (r *ExecRepository) Root() (string, error) {
+	out, err := r.output("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath.
+func (r *ExecRepository) HooksDir() (string, error) {
+	out, err := r.output("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.Dir, dir)
+	}
+	return filepath.Abs(dir)
+}
+
+// Tags lists the repository's tags, with annotated tags peeled to their
+// commit.
+func (r *ExecRepository) Tags() ([]Tag, error) {
+	out, err := r.output("for-each-ref", "--format=%(refname:short)%00%(objectname)%00%(*objectname)%00%(creatordate:unix)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		commit := fields[1]
+		if fields[2] != "" {
+			commit = fields[2]
+		}
+		seconds, _ := strconv.ParseInt(fields[3], 10, 64)
+		tags = append(tags, Tag{Name: fields[0], Commit: commit, Date: time.Unix(seconds, 0)})
+	}
+	return tags, nil
+}
+
+// CreateTag creates an annotated tag on HEAD, GPG-signed with sign.
+func (r *ExecRepository) CreateTag(name, message string, sign bool) error {
+	flag := "-a"
+	if sign {
+		flag = "-s"
+	}
+	return r.runInteractive("tag", flag, name, "-m", message)
+}
+
+func (r *ExecRepository) PushTag(name string) error {
+	return r.runInteractive("push", r.Remote, "refs/tags/"+name)
 }
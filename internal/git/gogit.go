@@ -0,0 +1,674 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gcm/internal/diff"
+	"gcm/internal/model"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+var installFileTransport sync.Once
+
+// GoGitRepository implements Repository with go-git, for environments
+// without a git binary on PATH.
+type GoGitRepository struct {
+	repo *gogit.Repository
+	root string
+
+	Remote string
+	Stdout io.Writer
+}
+
+func NewGoGitRepository(dir string) (*GoGitRepository, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, err
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	// go-git's file transport shells out to git-receive-pack; serve local
+	// remotes in-process instead.
+	installFileTransport.Do(func() {
+		client.InstallProtocol("file", server.DefaultServer)
+	})
+
+	return &GoGitRepository{
+		repo:   repo,
+		root:   wt.Filesystem.Root(),
+		Remote: DefaultRemote,
+		Stdout: os.Stdout,
+	}, nil
+}
+
+func (r *GoGitRepository) Status() ([]model.GitChange, error) {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []model.GitChange
+	for path, st := range status {
+		if st.Staging == gogit.Unmodified && st.Worktree == gogit.Unmodified {
+			continue
+		}
+		change := model.GitChange{
+			Index:    byte(st.Staging),
+			Working:  byte(st.Worktree),
+			Path:     path,
+			Unmerged: st.Staging == gogit.UpdatedButUnmerged,
+		}
+		if st.Staging == gogit.Renamed || st.Staging == gogit.Copied {
+			change.OrigPath = st.Extra
+		}
+		res = append(res, change)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+
+	return res, nil
+}
+
+func (r *GoGitRepository) CurrentBranch() (string, error) {
+	head, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		// Detached HEAD, like `git branch --show-current`
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+func (r *GoGitRepository) CreateBranch(branchName string) error {
+	name := plumbing.NewBranchReferenceName(branchName)
+
+	head, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// No commits yet: just point HEAD at the new branch
+		return r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, name))
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.repo.Reference(name, false); err == nil {
+		return fmt.Errorf("a branch named '%s' already exists", branchName)
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&gogit.CheckoutOptions{
+		Hash:   head.Hash(),
+		Branch: name,
+		Create: true,
+		Keep:   true,
+	})
+}
+
+func (r *GoGitRepository) CheckoutBranch(branchName string) error {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Checkout(&gogit.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branchName),
+		Keep:   true,
+	})
+}
+
+func (r *GoGitRepository) Add(paths []string) error {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := wt.Add(strings.TrimSuffix(path, "/")); err != nil {
+			return fmt.Errorf("adding %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (r *GoGitRepository) Unstage(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	_, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// No commits yet: unstaging means dropping the index entries
+		idx, err := r.repo.Storer.Index()
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			if _, err := idx.Remove(path); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+				return err
+			}
+		}
+		return r.repo.Storer.SetIndex(idx)
+	}
+	if err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.Reset(&gogit.ResetOptions{Mode: gogit.MixedReset, Files: paths})
+}
+
+// ApplyCached applies a single-file patch to the index blob of its path.
+func (r *GoGitRepository) ApplyCached(patch string) error {
+	file := diff.Parse(patch)
+	path := file.Path()
+
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return fmt.Errorf("%s: does not exist in index", path)
+	}
+
+	content, err := r.blob(entry.Hash)
+	if err != nil {
+		return err
+	}
+
+	updated, err := file.Apply(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	hash, err := r.writeBlob([]byte(updated))
+	if err != nil {
+		return err
+	}
+
+	entry.Hash = hash
+	entry.Size = uint32(len(updated))
+	return r.repo.Storer.SetIndex(idx)
+}
+
+func (r *GoGitRepository) Diff(change model.GitChange, staged bool) (string, error) {
+	var from, to *blobFile
+	var err error
+
+	switch {
+	case change.Index == '?':
+		to, err = r.worktreeFile(change.Path)
+	case staged:
+		fromPath := change.Path
+		if change.OrigPath != "" {
+			fromPath = change.OrigPath
+		}
+		if from, err = r.headFile(fromPath); err == nil {
+			to, err = r.indexFile(change.Path)
+		}
+	default:
+		if from, err = r.indexFile(change.Path); err == nil {
+			to, err = r.worktreeFile(change.Path)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return unifiedDiff(from, to)
+}
+
+// DiffStats counts the lines of each change's diff.
+func (r *GoGitRepository) DiffStats(changes []model.GitChange) ([]model.DiffStat, error) {
+	stats, err := diffStats(r, changes)
+	if err != nil {
+		return nil, err
+	}
+	return stats, fileSizes(r.root, changes, stats)
+}
+
+func (r *GoGitRepository) Commit(msg string) error {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	hash, err := wt.Commit(msg, &gogit.CommitOptions{})
+	if err != nil {
+		return err
+	}
+
+	branch, _ := r.CurrentBranch()
+	title, _, _ := strings.Cut(msg, "\n")
+	fmt.Fprintf(r.Stdout, "[%s %s] %s\n", branch, hash.String()[:7], title)
+	return nil
+}
+
+func (r *GoGitRepository) HasRemoteBranch(branchName string) (bool, error) {
+	_, err := r.repo.Reference(plumbing.NewRemoteReferenceName(r.Remote, branchName), true)
+	if err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// push sends ref to the same name on the remote.
+func (r *GoGitRepository) push(ref plumbing.ReferenceName) error {
+	err := r.repo.Push(&gogit.PushOptions{
+		RemoteName: r.Remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))},
+		Progress:   r.Stdout,
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+func (r *GoGitRepository) Push(branchName string, setUpstream bool) error {
+	ref := plumbing.NewBranchReferenceName(branchName)
+	if err := r.push(ref); err != nil {
+		return err
+	}
+
+	if !setUpstream {
+		return nil
+	}
+
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[branchName] = &config.Branch{
+		Name:   branchName,
+		Remote: r.Remote,
+		Merge:  ref,
+	}
+	return r.repo.SetConfig(cfg)
+}
+
+// ConfigValues returns every value of a multi-valued git config key from
+// the repository and global config, or nil when it is unset.
+func (r *GoGitRepository) ConfigValues(key string) ([]string, error) {
+	cfg, err := r.repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(key, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("key does not contain a section: %s", key)
+	}
+
+	section := cfg.Raw.Section(parts[0])
+	option := parts[len(parts)-1]
+	if len(parts) > 2 {
+		return section.Subsection(strings.Join(parts[1:len(parts)-1], ".")).OptionAll(option), nil
+	}
+	return section.OptionAll(option), nil
+}
+
+// Authors lists the "Name <email>" identities of recent commit authors,
+// most frequent first.
+func (r *GoGitRepository) Authors() ([]string, error) {
+	iter, err := r.repo.Log(&gogit.LogOptions{})
+	if err != nil {
+		// No commits yet
+		return nil, nil
+	}
+	defer iter.Close()
+
+	var authors []string
+	for len(authors) < authorHistory {
+		commit, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email))
+	}
+
+	return rankAuthors(authors), nil
+}
+
+// Log lists the commits of a revision range, newest first. It supports
+// "A..B", "..B", "A.." and single revisions.
+func (r *GoGitRepository) Log(revRange string) ([]Commit, error) {
+	from, to, isRange := strings.Cut(revRange, "..")
+	if !isRange {
+		from, to = "", revRange
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	head, err := r.repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", to, err)
+	}
+
+	// Commits reachable from the start of the range are left out
+	exclude := make(map[plumbing.Hash]bool)
+	if isRange {
+		if from == "" {
+			from = "HEAD"
+		}
+		base, err := r.repo.ResolveRevision(plumbing.Revision(from))
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", from, err)
+		}
+		iter, err := r.repo.Log(&gogit.LogOptions{From: *base})
+		if err != nil {
+			return nil, err
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	iter, err := r.repo.Log(&gogit.LogOptions{From: *head})
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if !exclude[c.Hash] {
+			commits = append(commits, Commit{Hash: c.Hash.String(), Message: c.Message})
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// ChangedPaths lists the paths each of the latest limit commits touched,
+// newest first. Merge commits are left out.
+func (r *GoGitRepository) ChangedPaths(limit int) ([][]string, error) {
+	iter, err := r.repo.Log(&gogit.LogOptions{})
+	if err != nil {
+		// No commits yet
+		return nil, nil
+	}
+	defer iter.Close()
+
+	var history [][]string
+	for len(history) < limit {
+		commit, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if commit.NumParents() > 1 {
+			continue
+		}
+
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		var parentTree *object.Tree
+		if commit.NumParents() == 1 {
+			parent, err := commit.Parent(0)
+			if err != nil {
+				return nil, err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+
+		var paths []string
+		for _, ch := range changes {
+			name := ch.To.Name
+			if name == "" {
+				name = ch.From.Name
+			}
+			paths = append(paths, name)
+		}
+		if len(paths) > 0 {
+			history = append(history, paths)
+		}
+	}
+
+	return history, nil
+}
+
+func (r *GoGitRepository) Root() (string, error) {
+	return r.root, nil
+}
+
+// HooksDir returns core.hooksPath, relative to the worktree root, or the
+// hooks directory of the common git directory, which linked worktrees
+// share with the main one.
+func (r *GoGitRepository) HooksDir() (string, error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	if dir := cfg.Raw.Section("core").Option("hooksPath"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(r.root, dir)
+		}
+		return dir, nil
+	}
+
+	dir, err := r.commonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hooks"), nil
+}
+
+// commonDir returns the git directory of the repository: the one the
+// storer reads, such as .git/modules/<name> for a submodule, or the one
+// its commondir file points to for a linked worktree.
+func (r *GoGitRepository) commonDir() (string, error) {
+	storage, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", errors.New("the repository is not stored on disk")
+	}
+
+	dir := storage.Filesystem().Root()
+	data, err := os.ReadFile(filepath.Join(dir, "commondir"))
+	if errors.Is(err, os.ErrNotExist) {
+		return dir, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(dir, common)
+	}
+	return filepath.Clean(common), nil
+}
+
+// Tags lists the repository's tags, with annotated tags peeled to their
+// commit.
+func (r *GoGitRepository) Tags() ([]Tag, error) {
+	iter, err := r.repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		t := Tag{Name: ref.Name().Short(), Commit: ref.Hash().String()}
+		if tag, err := r.repo.TagObject(ref.Hash()); err == nil {
+			t.Commit, t.Date = tag.Target.String(), tag.Tagger.When
+		} else if c, err := r.repo.CommitObject(ref.Hash()); err == nil {
+			t.Date = c.Committer.When
+		}
+		tags = append(tags, t)
+		return nil
+	})
+	return tags, err
+}
+
+// CreateTag creates an annotated tag on HEAD. go-git can't reach the
+// user's GPG agent, so signed tags need the exec backend.
+func (r *GoGitRepository) CreateTag(name, message string, sign bool) error {
+	if sign {
+		return fmt.Errorf("signed tags are not supported by the %s backend, use %s", BackendGoGit, BackendExec)
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.repo.CreateTag(name, head.Hash(), &gogit.CreateTagOptions{Message: message})
+	return err
+}
+
+func (r *GoGitRepository) PushTag(name string) error {
+	return r.push(plumbing.NewTagReferenceName(name))
+}
+
+func (r *GoGitRepository) blob(hash plumbing.Hash) ([]byte, error) {
+	blob, err := r.repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func (r *GoGitRepository) writeBlob(content []byte) (plumbing.Hash, error) {
+	obj := r.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return r.repo.Storer.SetEncodedObject(obj)
+}
+
+func (r *GoGitRepository) headFile(path string) (*blobFile, error) {
+	head, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	content, err := r.blob(file.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return &blobFile{path: path, mode: file.Mode, hash: file.Hash, content: content}, nil
+}
+
+func (r *GoGitRepository) indexFile(path string) (*blobFile, error) {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := idx.Entry(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	content, err := r.blob(entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return &blobFile{path: path, mode: entry.Mode, hash: entry.Hash, content: content}, nil
+}
+
+func (r *GoGitRepository) worktreeFile(path string) (*blobFile, error) {
+	full := filepath.Join(r.root, path)
+	info, err := os.Lstat(full)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		// Symlinks are stored as their target, like git does
+		target, err := os.Readlink(full)
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(target)
+	} else if content, err = os.ReadFile(full); err != nil {
+		return nil, err
+	}
+	return newWorktreeFile(path, info, content), nil
+}
@@ -0,0 +1,123 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// blobFile is one side of a diff, read from HEAD, the index or the working
+// tree.
+type blobFile struct {
+	path    string
+	mode    filemode.FileMode
+	hash    plumbing.Hash
+	content []byte
+}
+
+func newWorktreeFile(path string, info os.FileInfo, content []byte) *blobFile {
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		mode = filemode.Regular
+	}
+	return &blobFile{
+		path:    path,
+		mode:    mode,
+		hash:    plumbing.ComputeHash(plumbing.BlobObject, content),
+		content: content,
+	}
+}
+
+func (f *blobFile) Hash() plumbing.Hash     { return f.hash }
+func (f *blobFile) Mode() filemode.FileMode { return f.mode }
+func (f *blobFile) Path() string            { return f.path }
+
+type filePatch struct {
+	from, to *blobFile
+	chunks   []fdiff.Chunk
+}
+
+func (p filePatch) IsBinary() bool {
+	for _, f := range []*blobFile{p.from, p.to} {
+		if f != nil && bytes.IndexByte(f.content, 0) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p filePatch) Files() (fdiff.File, fdiff.File) {
+	// A nil *blobFile must come out as a nil interface
+	var from, to fdiff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+func (p filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string       { return c.content }
+func (c chunk) Type() fdiff.Operation { return c.op }
+
+var indexLine = regexp.MustCompile(`(?m)^index ([0-9a-f]{7})[0-9a-f]{33}\.\.([0-9a-f]{7})[0-9a-f]{33}`)
+
+type patch []fdiff.FilePatch
+
+func (p patch) FilePatches() []fdiff.FilePatch { return p }
+func (p patch) Message() string                { return "" }
+
+// unifiedDiff renders the change from one blob to another the way
+// `git diff` prints it. Either side may be nil.
+func unifiedDiff(from, to *blobFile) (string, error) {
+	if from == nil && to == nil {
+		return "", nil
+	}
+	if from != nil && to != nil && from.hash == to.hash && from.mode == to.mode {
+		return "", nil
+	}
+
+	fp := filePatch{from: from, to: to}
+	if !fp.IsBinary() {
+		var src, dst string
+		if from != nil {
+			src = string(from.content)
+		}
+		if to != nil {
+			dst = string(to.content)
+		}
+		for _, d := range diff.Do(src, dst) {
+			op := fdiff.Equal
+			switch d.Type {
+			case diffmatchpatch.DiffInsert:
+				op = fdiff.Add
+			case diffmatchpatch.DiffDelete:
+				op = fdiff.Delete
+			}
+			fp.chunks = append(fp.chunks, chunk{content: d.Text, op: op})
+		}
+	}
+
+	var b strings.Builder
+	if err := fdiff.NewUnifiedEncoder(&b, fdiff.DefaultContextLines).Encode(patch{fp}); err != nil {
+		return "", err
+	}
+
+	// Abbreviate object names like `git diff` does
+	return indexLine.ReplaceAllString(b.String(), "index $1..$2"), nil
+}
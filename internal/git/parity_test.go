@@ -0,0 +1,419 @@
+package git
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gcm/internal/model"
+)
+
+// The parity tests run the same steps through both backends, each on a
+// fresh copy of the same repository, and compare what they report.
+
+type backend struct {
+	name string
+	open func(t *testing.T, dir string) Repository
+}
+
+var backends = []backend{
+	{"exec", func(t *testing.T, dir string) Repository {
+		r := NewExecRepository(dir)
+		r.Stdout, r.Stderr = io.Discard, io.Discard
+		return r
+	}},
+	{"go-git", func(t *testing.T, dir string) Repository {
+		r, err := NewGoGitRepository(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Stdout = io.Discard
+		return r
+	}},
+}
+
+// gitCmd runs git in dir and returns its trimmed output.
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	path = filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRepo creates a repository on main with one commit of a.txt, b.txt
+// and c.txt, isolated from the user's git configuration.
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+	gitCmd(t, dir, "config", "user.name", "Ada Lovelace")
+	gitCmd(t, dir, "config", "user.email", "ada@example.com")
+	writeFile(t, dir, "a.txt", "one\ntwo\nthree\n")
+	writeFile(t, dir, "b.txt", "alpha\nbeta\n")
+	writeFile(t, dir, "c.txt", "keep\n")
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+// changed reports the fields both backends fill in, by path.
+func changed(changes []model.GitChange) []model.GitChange {
+	res := make([]model.GitChange, len(changes))
+	for i, c := range changes {
+		res[i] = model.GitChange{Index: c.Index, Working: c.Working, Path: c.Path, OrigPath: c.OrigPath}
+	}
+	slices.SortFunc(res, func(a, b model.GitChange) int { return strings.Compare(a.Path, b.Path) })
+	return res
+}
+
+// Worktree states both backends report alike
+var statusCases = []struct {
+	name  string
+	setup func(t *testing.T, dir string)
+	want  []model.GitChange
+}{
+	{
+		name:  "clean",
+		setup: func(t *testing.T, dir string) {},
+		want:  []model.GitChange{},
+	},
+	{
+		name: "modified",
+		setup: func(t *testing.T, dir string) {
+			writeFile(t, dir, "a.txt", "one\n2\nthree\n")
+		},
+		want: []model.GitChange{{Index: ' ', Working: 'M', Path: "a.txt"}},
+	},
+	{
+		name: "staged and modified again",
+		setup: func(t *testing.T, dir string) {
+			writeFile(t, dir, "a.txt", "one\n2\nthree\n")
+			gitCmd(t, dir, "add", "a.txt")
+			writeFile(t, dir, "a.txt", "one\n2\n3\n")
+		},
+		want: []model.GitChange{{Index: 'M', Working: 'M', Path: "a.txt"}},
+	},
+	{
+		name: "deleted and added",
+		setup: func(t *testing.T, dir string) {
+			os.Remove(filepath.Join(dir, "b.txt"))
+			gitCmd(t, dir, "rm", "-q", "c.txt")
+			writeFile(t, dir, "d.txt", "new\n")
+			gitCmd(t, dir, "add", "d.txt")
+		},
+		want: []model.GitChange{
+			{Index: ' ', Working: 'D', Path: "b.txt"},
+			{Index: 'D', Working: ' ', Path: "c.txt"},
+			{Index: 'A', Working: ' ', Path: "d.txt"},
+		},
+	},
+	{
+		name: "untracked file",
+		setup: func(t *testing.T, dir string) {
+			writeFile(t, dir, "new.txt", "fresh\n")
+		},
+		want: []model.GitChange{{Index: '?', Working: '?', Path: "new.txt"}},
+	},
+}
+
+func TestParityStatus(t *testing.T) {
+	for _, tt := range statusCases {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, b := range backends {
+				dir := newRepo(t)
+				tt.setup(t, dir)
+				got, err := b.open(t, dir).Status()
+				if err != nil {
+					t.Fatalf("%s: %v", b.name, err)
+				}
+				if got := changed(got); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: Status = %+v, want %+v", b.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestParityStatusGaps pins down where the backends disagree, so that a
+// change on either side shows up here.
+func TestParityStatusGaps(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+		want  map[string][]model.GitChange
+	}{
+		{
+			// go-git doesn't detect renames in the index
+			name: "staged rename",
+			setup: func(t *testing.T, dir string) {
+				gitCmd(t, dir, "mv", "a.txt", "moved.txt")
+			},
+			want: map[string][]model.GitChange{
+				"exec": {{Index: 'R', Working: ' ', Path: "moved.txt", OrigPath: "a.txt"}},
+				"go-git": {
+					{Index: 'D', Working: ' ', Path: "a.txt"},
+					{Index: 'A', Working: ' ', Path: "moved.txt"},
+				},
+			},
+		},
+		{
+			// git collapses an untracked directory, go-git lists its files
+			name: "untracked directory",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, dir, "new/x.txt", "x\n")
+				writeFile(t, dir, "new/y.txt", "y\n")
+			},
+			want: map[string][]model.GitChange{
+				"exec": {{Index: '?', Working: '?', Path: "new/"}},
+				"go-git": {
+					{Index: '?', Working: '?', Path: "new/x.txt"},
+					{Index: '?', Working: '?', Path: "new/y.txt"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, b := range backends {
+				dir := newRepo(t)
+				tt.setup(t, dir)
+				got, err := b.open(t, dir).Status()
+				if err != nil {
+					t.Fatalf("%s: %v", b.name, err)
+				}
+				if got := changed(got); !slices.Equal(got, tt.want[b.name]) {
+					t.Errorf("%s: Status = %+v, want %+v", b.name, got, tt.want[b.name])
+				}
+			}
+		})
+	}
+}
+
+func TestParityDiff(t *testing.T) {
+	setup := func(t *testing.T, dir string) {
+		writeFile(t, dir, "a.txt", "one\n2\nthree\n")
+		gitCmd(t, dir, "add", "a.txt")
+		writeFile(t, dir, "a.txt", "one\n2\nthree\nfour\n")
+		os.Remove(filepath.Join(dir, "b.txt"))
+		writeFile(t, dir, "new.txt", "fresh\nlines\n")
+	}
+	tests := []struct {
+		change model.GitChange
+		staged bool
+	}{
+		{model.GitChange{Index: 'M', Working: 'M', Path: "a.txt"}, true},
+		{model.GitChange{Index: 'M', Working: 'M', Path: "a.txt"}, false},
+		{model.GitChange{Index: ' ', Working: 'D', Path: "b.txt"}, false},
+		{model.GitChange{Index: '?', Working: '?', Path: "new.txt"}, false},
+	}
+
+	diffs := make(map[string][]string)
+	stats := make(map[string][]model.DiffStat)
+	for _, b := range backends {
+		dir := newRepo(t)
+		setup(t, dir)
+		repo := b.open(t, dir)
+
+		var changes []model.GitChange
+		for _, tt := range tests {
+			text, err := repo.Diff(tt.change, tt.staged)
+			if err != nil {
+				t.Fatalf("%s: Diff(%s, staged %v): %v", b.name, tt.change.Path, tt.staged, err)
+			}
+			diffs[b.name] = append(diffs[b.name], text)
+			changes = append(changes, tt.change)
+		}
+
+		var err error
+		if stats[b.name], err = repo.DiffStats(changes); err != nil {
+			t.Fatalf("%s: DiffStats: %v", b.name, err)
+		}
+	}
+
+	for i, tt := range tests {
+		exec, gogit := diffs["exec"][i], diffs["go-git"][i]
+		if exec == "" {
+			t.Errorf("exec: Diff(%s, staged %v) is empty", tt.change.Path, tt.staged)
+		}
+		if exec != gogit {
+			t.Errorf("Diff(%s, staged %v) differs:\nexec:\n%s\ngo-git:\n%s", tt.change.Path, tt.staged, exec, gogit)
+		}
+	}
+
+	// DiffStats counts the staged lines of a.txt, as it is partly staged
+	want := []model.DiffStat{
+		{Added: 1, Removed: 1, Size: 17},
+		{Added: 1, Removed: 1, Size: 17},
+		{Added: 0, Removed: 2},
+		{Added: 2, Removed: 0, Size: 12},
+	}
+	for _, b := range backends {
+		if !slices.Equal(stats[b.name], want) {
+			t.Errorf("%s: DiffStats = %+v, want %+v", b.name, stats[b.name], want)
+		}
+	}
+}
+
+func TestParityCommit(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			dir := newRepo(t)
+			repo := b.open(t, dir)
+
+			writeFile(t, dir, "a.txt", "one\n2\nthree\n")
+			writeFile(t, dir, "b.txt", "alpha\nb\n")
+			writeFile(t, dir, "new/x.txt", "x\n")
+			if err := repo.Add([]string{"a.txt", "new/"}); err != nil {
+				t.Fatal(err)
+			}
+
+			status, err := repo.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []model.GitChange{
+				{Index: 'M', Working: ' ', Path: "a.txt"},
+				{Index: ' ', Working: 'M', Path: "b.txt"},
+				{Index: 'A', Working: ' ', Path: "new/x.txt"},
+			}
+			if got := (changed(status)); !slices.Equal(got, want) {
+				t.Fatalf("Status after Add = %+v, want %+v", got, want)
+			}
+
+			msg := "feat(core): add x\n\nBody text.\n\nRefs: #12"
+			if err := repo.Commit(msg); err != nil {
+				t.Fatal(err)
+			}
+			if got := gitCmd(t, dir, "log", "-1", "--format=%B"); got != msg {
+				t.Errorf("message = %q, want %q", got, msg)
+			}
+			if got := gitCmd(t, dir, "log", "-1", "--format=%an <%ae>"); got != "Ada Lovelace <ada@example.com>" {
+				t.Errorf("author = %q", got)
+			}
+			if got := gitCmd(t, dir, "show", "--name-only", "--format=", "HEAD"); got != "a.txt\nnew/x.txt" {
+				t.Errorf("committed %q, want a.txt and new/x.txt", got)
+			}
+			if got := gitCmd(t, dir, "status", "--porcelain"); got != "M b.txt" {
+				t.Errorf("left %q, want b.txt modified", got)
+			}
+		})
+	}
+}
+
+func TestParityBranchAndPush(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			dir := newRepo(t)
+			remote := t.TempDir()
+			gitCmd(t, remote, "init", "-q", "--bare")
+			gitCmd(t, dir, "remote", "add", DefaultRemote, remote)
+			repo := b.open(t, dir)
+
+			writeFile(t, dir, "a.txt", "one\n2\nthree\n")
+			if err := repo.CreateBranch("feat/12-thing"); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := repo.CurrentBranch(); err != nil || got != "feat/12-thing" {
+				t.Fatalf("CurrentBranch = %q, %v", got, err)
+			}
+			if err := repo.CreateBranch("feat/12-thing"); err == nil {
+				t.Error("creating an existing branch succeeded")
+			}
+			// The worktree changes come along to the new branch
+			if got := gitCmd(t, dir, "status", "--porcelain"); got != "M a.txt" {
+				t.Errorf("status on the new branch = %q, want a.txt modified", got)
+			}
+
+			if err := repo.Add([]string{"a.txt"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Commit("fix: two"); err != nil {
+				t.Fatal(err)
+			}
+
+			if ok, _ := repo.HasRemoteBranch("feat/12-thing"); ok {
+				t.Error("the branch is on the remote before the push")
+			}
+			if err := repo.Push("feat/12-thing", true); err != nil {
+				t.Fatal(err)
+			}
+			head := gitCmd(t, dir, "rev-parse", "HEAD")
+			if got := gitCmd(t, remote, "rev-parse", "refs/heads/feat/12-thing"); got != head {
+				t.Errorf("remote branch at %s, want %s", got, head)
+			}
+			if ok, err := repo.HasRemoteBranch("feat/12-thing"); err != nil || !ok {
+				t.Errorf("HasRemoteBranch after the push = %v, %v", ok, err)
+			}
+			if got := gitCmd(t, dir, "config", "branch.feat/12-thing.remote"); got != DefaultRemote {
+				t.Errorf("upstream remote = %q, want %s", got, DefaultRemote)
+			}
+			if got := gitCmd(t, dir, "config", "branch.feat/12-thing.merge"); got != "refs/heads/feat/12-thing" {
+				t.Errorf("upstream branch = %q", got)
+			}
+		})
+	}
+}
+
+func TestHooksDir(t *testing.T) {
+	dir := newRepo(t)
+	gitCmd(t, dir, "worktree", "add", "-q", filepath.Join(t.TempDir(), "wt"), "-b", "side")
+	worktree := gitCmd(t, dir, "worktree", "list", "--porcelain")
+	_, worktree, _ = strings.Cut(worktree, "\nworktree ")
+	worktree, _, _ = strings.Cut(worktree, "\n")
+
+	common, err := filepath.EvalSymlinks(filepath.Join(dir, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{dir, worktree} {
+		for _, b := range backends {
+			got, err := b.open(t, d).HooksDir()
+			if err != nil {
+				t.Fatalf("%s: %v", b.name, err)
+			}
+			if got, _ = filepath.EvalSymlinks(got); got != filepath.Join(common, "hooks") {
+				t.Errorf("%s: HooksDir in %s = %s, want %s", b.name, d, got, filepath.Join(common, "hooks"))
+			}
+		}
+	}
+
+	gitCmd(t, dir, "config", "core.hooksPath", ".githooks")
+	for _, b := range backends {
+		got, err := b.open(t, dir).HooksDir()
+		if err != nil {
+			t.Fatalf("%s: %v", b.name, err)
+		}
+		if want := filepath.Join(dir, ".githooks"); got != want {
+			t.Errorf("%s: HooksDir with core.hooksPath = %s, want %s", b.name, got, want)
+		}
+	}
+}
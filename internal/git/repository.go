@@ -0,0 +1,159 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gcm/internal/diff"
+	"gcm/internal/model"
+)
+
+// Repository is the set of git operations the commit workflow depends on.
+type Repository interface {
+	Status() ([]model.GitChange, error)
+	CurrentBranch() (string, error)
+	CreateBranch(name string) error
+	CheckoutBranch(name string) error
+	Add(paths []string) error
+	Unstage(paths []string) error
+	ApplyCached(patch string) error
+	Diff(change model.GitChange, staged bool) (string, error)
+	DiffStats(changes []model.GitChange) ([]model.DiffStat, error)
+	Commit(msg string) error
+	HasRemoteBranch(branch string) (bool, error)
+	Push(branch string, setUpstream bool) error
+	ConfigValues(key string) ([]string, error)
+	Authors() ([]string, error)
+	Log(revRange string) ([]Commit, error)
+	ChangedPaths(limit int) ([][]string, error)
+	HooksDir() (string, error)
+	Root() (string, error)
+	Tags() ([]Tag, error)
+	CreateTag(name, message string, sign bool) error
+	PushTag(name string) error
+}
+
+// statOf counts the lines of a diff as printed by Diff.
+func statOf(text string) model.DiffStat {
+	f := diff.Parse(text)
+	added, removed := f.Stat()
+	return model.DiffStat{Added: added, Removed: removed, Binary: f.Binary()}
+}
+
+// diffStats runs Diff on each change and counts its lines, for the
+// backends without a cheaper way.
+func diffStats(r Repository, changes []model.GitChange) ([]model.DiffStat, error) {
+	stats := make([]model.DiffStat, len(changes))
+	for i, c := range changes {
+		text, err := r.Diff(c, c.IsStaged())
+		if err != nil {
+			return nil, fmt.Errorf("diff of %s: %w", c.Path, err)
+		}
+		stats[i] = statOf(text)
+	}
+	return stats, nil
+}
+
+// fileSizes fills in the Size of each change's stat from its file below
+// root. Deleted files, directories and submodules are left at 0.
+func fileSizes(root string, changes []model.GitChange, stats []model.DiffStat) error {
+	for i, c := range changes {
+		info, err := os.Lstat(filepath.Join(root, c.Path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			stats[i].Size = info.Size()
+		}
+	}
+	return nil
+}
+
+// Commit is one entry of Log.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Tag is a tag and the commit it points to.
+type Tag struct {
+	Name   string
+	Commit string
+	Date   time.Time // when an annotated tag was made, else the commit date
+}
+
+// authorHistory is how many recent commits Authors looks at.
+const authorHistory = 500
+
+// rankAuthors dedupes "Name <email>" identities, most frequent first.
+func rankAuthors(authors []string) []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, a := range authors {
+		if counts[a] == 0 {
+			order = append(order, a)
+		}
+		counts[a]++
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	return order
+}
+
+// IsProtectedBranch reports whether branch matches one of the glob
+// patterns, e.g. "main" or "release/*", that must not be committed to.
+func IsProtectedBranch(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRemote is pushed to unless Open is given another remote.
+const DefaultRemote = "origin"
+
+// Backends accepted by Open.
+const (
+	BackendExec  = "exec"
+	BackendGoGit = "go-git"
+)
+
+// Open returns the Repository implementation for backend, rooted at dir
+// and pushing to remote.
+func Open(backend, dir, remote string) (Repository, error) {
+	if remote == "" {
+		remote = DefaultRemote
+	}
+
+	switch backend {
+	case "", BackendExec:
+		repo := NewExecRepository(dir)
+		repo.Remote = remote
+
+		// Status paths are relative to the top level, so git runs there
+		root, err := repo.Root()
+		if err != nil {
+			return nil, fmt.Errorf("not a git repository: %w", err)
+		}
+		repo.Dir = root
+		return repo, nil
+	case BackendGoGit:
+		repo, err := NewGoGitRepository(dir)
+		if err != nil {
+			return nil, err
+		}
+		repo.Remote = remote
+		return repo, nil
+	}
+	return nil, fmt.Errorf("unknown git backend %q (want %q or %q)", backend, BackendExec, BackendGoGit)
+}
@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+type Severity int
+
+const (
+	Warning Severity = config.LevelWarning
+	Error   Severity = config.LevelError
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Violation is a rule a commit message breaks.
+type Violation struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s [%s]", v.Message, v.Rule)
+}
+
+// HasErrors reports whether any violation is an error, which blocks the
+// commit; warnings don't.
+func HasErrors(violations []Violation) bool {
+	return slices.ContainsFunc(violations, func(v Violation) bool { return v.Severity == Error })
+}
+
+type configured struct {
+	id      string
+	rule    rule
+	setting config.RuleSetting
+}
+
+// Engine checks commit messages against a set of configured rules.
+type Engine struct {
+	rules []configured
+}
+
+// New configures every known rule from the defaults derived from cfg,
+// overridden by cfg.Rules. scopes is the allowed scope catalogue; empty
+// allows any scope.
+func New(cfg config.Config, scopes []string) (*Engine, error) {
+	settings := defaults(cfg, scopes)
+	for id, setting := range cfg.Rules {
+		if _, ok := rules[id]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", id)
+		}
+		if setting.Value == nil {
+			// [level, when] keeps the value derived from the config
+			setting.Value = settings[id].Value
+		}
+		settings[id] = setting
+	}
+
+	e := &Engine{}
+	for _, id := range ruleOrder {
+		setting := settings[id]
+		if setting.Level == config.LevelOff {
+			continue
+		}
+		if validate := rules[id].validate; validate != nil {
+			if err := validate(setting.Value); err != nil {
+				return nil, fmt.Errorf("lint rule %s: %w", id, err)
+			}
+		}
+		e.rules = append(e.rules, configured{id: id, rule: rules[id], setting: setting})
+	}
+	return e, nil
+}
+
+// Lint returns every violation of the message, errors first. With targets
+// (e.g. "subject", "footer") only the rules about those parts run.
+func (e *Engine) Lint(info model.CommitInfo, targets ...string) []Violation {
+	var res []Violation
+	for _, c := range e.rules {
+		if len(targets) > 0 && !slices.Contains(targets, c.rule.target) {
+			continue
+		}
+
+		v := c.rule.check(info, c.setting.Value)
+		if v.skip {
+			continue
+		}
+
+		never := c.setting.When == "never"
+		if v.holds != never {
+			continue
+		}
+
+		msg := c.rule.target + " must "
+		if never {
+			msg += "not "
+		}
+		msg += c.rule.describe(c.setting.Value)
+		if v.detail != "" {
+			msg += " (" + v.detail + ")"
+		}
+
+		res = append(res, Violation{Rule: c.id, Severity: Severity(c.setting.Level), Message: msg})
+	}
+
+	slices.SortStableFunc(res, func(a, b Violation) int { return int(b.Severity - a.Severity) })
+	return res
+}
+
+// HeaderTargets are the parts of the message the title step covers.
+var HeaderTargets = []string{"header", "type", "scope", "subject"}
+
+// Summary joins the violations into one line for error messages.
+func Summary(violations []Violation) string {
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = v.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Messages git or other tools generate, which the rules don't apply to
+var ignoredPrefixes = []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "}
+
+// Ignored reports whether a raw message was generated by git, e.g. for a
+// merge, revert or fixup commit, and shouldn't be linted.
+func Ignored(message string) bool {
+	for _, prefix := range ignoredPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
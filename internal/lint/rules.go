@@ -0,0 +1,324 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+// verdict is the outcome of a rule's condition on one message.
+type verdict struct {
+	holds  bool
+	skip   bool   // the rule doesn't apply, e.g. subject-case on an empty subject
+	detail string // shown after the message, e.g. the actual length
+}
+
+// rule is a condition on a part of the message. "always" settings report
+// messages where it doesn't hold, "never" settings those where it does.
+type rule struct {
+	target   string
+	describe func(value any) string
+	check    func(info model.CommitInfo, value any) verdict
+	validate func(value any) error
+}
+
+var ruleOrder = []string{
+	"type-empty",
+	"type-enum",
+	"scope-enum",
+	"subject-empty",
+	"subject-min-length",
+	"subject-max-length",
+	"subject-case",
+	"subject-full-stop",
+	"subject-imperative",
+	"header-max-length",
+	"body-empty",
+	"body-max-line-length",
+	"footer-format",
+	"breaking-change-footer",
+}
+
+var rules = map[string]rule{
+	"type-empty": {
+		target:   "type",
+		describe: constant("be empty"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			return verdict{holds: info.Type == ""}
+		},
+	},
+	"type-enum": {
+		target:   "type",
+		describe: func(v any) string { return "be one of " + strings.Join(toStrings(v), ", ") },
+		check: func(info model.CommitInfo, v any) verdict {
+			return verdict{holds: slices.Contains(toStrings(v), info.Type), skip: info.Type == ""}
+		},
+		validate: isStrings,
+	},
+	"scope-enum": {
+		target:   "scope",
+		describe: func(v any) string { return "be one of " + strings.Join(toStrings(v), ", ") },
+		check: func(info model.CommitInfo, v any) verdict {
+			allowed := toStrings(v)
+			return verdict{holds: slices.Contains(allowed, info.Scope), skip: info.Scope == "" || len(allowed) == 0}
+		},
+		validate: isStrings,
+	},
+	"subject-empty": {
+		target:   "subject",
+		describe: constant("be empty"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			return verdict{holds: strings.TrimSpace(info.Title) == ""}
+		},
+	},
+	"subject-min-length": {
+		target:   "subject",
+		describe: func(v any) string { return fmt.Sprintf("be at least %d characters", toInt(v)) },
+		check: func(info model.CommitInfo, v any) verdict {
+			n := len(info.Title)
+			return verdict{holds: n >= toInt(v), skip: n == 0, detail: fmt.Sprintf("is %d", n)}
+		},
+		validate: isInt,
+	},
+	"subject-max-length": {
+		target:   "subject",
+		describe: func(v any) string { return fmt.Sprintf("be at most %d characters", toInt(v)) },
+		check: func(info model.CommitInfo, v any) verdict {
+			n := len(info.Title)
+			return verdict{holds: n <= toInt(v), detail: fmt.Sprintf("is %d", n)}
+		},
+		validate: isInt,
+	},
+	"subject-case": {
+		target:   "subject",
+		describe: func(v any) string { return "be " + toString(v) },
+		check: func(info model.CommitInfo, v any) verdict {
+			if info.Title == "" {
+				return verdict{skip: true}
+			}
+			first := []rune(info.Title)[0]
+			switch toString(v) {
+			case "lower-case":
+				return verdict{holds: !unicode.IsUpper(first)}
+			case "sentence-case":
+				return verdict{holds: !unicode.IsLower(first)}
+			default: // upper-case
+				return verdict{holds: strings.ToUpper(info.Title) == info.Title}
+			}
+		},
+		validate: func(v any) error {
+			switch toString(v) {
+			case "lower-case", "sentence-case", "upper-case":
+				return nil
+			}
+			return fmt.Errorf("value must be lower-case, sentence-case or upper-case")
+		},
+	},
+	"subject-full-stop": {
+		target:   "subject",
+		describe: func(v any) string { return fmt.Sprintf("end with '%s'", toString(v)) },
+		check: func(info model.CommitInfo, v any) verdict {
+			return verdict{holds: strings.HasSuffix(info.Title, toString(v)), skip: info.Title == ""}
+		},
+		validate: isString,
+	},
+	"subject-imperative": {
+		target:   "subject",
+		describe: constant("use the imperative mood, e.g. 'add' instead of 'added' or 'adds'"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			word, _, _ := strings.Cut(strings.TrimSpace(info.Title), " ")
+			return verdict{holds: isImperative(word), skip: word == "", detail: "starts with '" + word + "'"}
+		},
+	},
+	"header-max-length": {
+		target:   "header",
+		describe: func(v any) string { return fmt.Sprintf("be at most %d characters", toInt(v)) },
+		check: func(info model.CommitInfo, v any) verdict {
+			n := len(info.Header())
+			return verdict{holds: n <= toInt(v), detail: fmt.Sprintf("is %d", n)}
+		},
+		validate: isInt,
+	},
+	"body-empty": {
+		target:   "body",
+		describe: constant("be empty"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			return verdict{holds: strings.TrimSpace(info.Description) == ""}
+		},
+	},
+	"body-max-line-length": {
+		target:   "body",
+		describe: func(v any) string { return fmt.Sprintf("have lines of at most %d characters", toInt(v)) },
+		check: func(info model.CommitInfo, v any) verdict {
+			longest := 0
+			for _, line := range strings.Split(info.Description, "\n") {
+				longest = max(longest, len(line))
+			}
+			return verdict{holds: longest <= toInt(v), skip: info.Description == "", detail: fmt.Sprintf("longest is %d", longest)}
+		},
+		validate: isInt,
+	},
+	"footer-format": {
+		target:   "footer",
+		describe: constant("be 'Token: value' trailers"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			for _, t := range info.Trailers {
+				if err := model.ValidateTrailer(t); err != nil {
+					return verdict{detail: err.Error()}
+				}
+			}
+			return verdict{holds: true, skip: len(info.Trailers) == 0}
+		},
+	},
+	"breaking-change-footer": {
+		target:   "footer",
+		describe: constant("have a BREAKING CHANGE footer exactly when the header has the '!' marker"),
+		check: func(info model.CommitInfo, _ any) verdict {
+			hasFooter := strings.TrimSpace(info.BreakingChange) != ""
+			return verdict{holds: info.Breaking == hasFooter}
+		},
+	},
+}
+
+// defaults derives the rule settings from the configured types, scopes and
+// title rules.
+func defaults(cfg config.Config, scopes []string) map[string]config.RuleSetting {
+	always := func(level int, value any) config.RuleSetting {
+		return config.RuleSetting{Level: level, When: "always", Value: value}
+	}
+	never := func(level int, value any) config.RuleSetting {
+		return config.RuleSetting{Level: level, When: "never", Value: value}
+	}
+
+	types := make([]string, len(cfg.Types))
+	for i, t := range cfg.Types {
+		types[i] = t.Key
+	}
+
+	subjectCase := always(config.LevelError, "lower-case")
+	switch cfg.Title.Case {
+	case config.CaseUpper:
+		subjectCase.Value = "sentence-case"
+	case config.CaseAny:
+		subjectCase.Level = config.LevelOff
+	}
+
+	fullStop := never(config.LevelError, ".")
+	if cfg.Title.AllowPeriod {
+		fullStop.Level = config.LevelOff
+	}
+
+	return map[string]config.RuleSetting{
+		"type-empty": never(config.LevelError, nil),
+		// Custom types are allowed in the type step, so only warn
+		"type-enum":              always(config.LevelWarning, types),
+		"scope-enum":             always(config.LevelError, scopes),
+		"subject-empty":          never(config.LevelError, nil),
+		"subject-min-length":     always(config.LevelError, cfg.Title.MinLength),
+		"subject-max-length":     always(config.LevelError, cfg.Title.MaxLength),
+		"subject-case":           subjectCase,
+		"subject-full-stop":      fullStop,
+		"subject-imperative":     always(config.LevelWarning, nil),
+		"header-max-length":      always(config.LevelError, 100),
+		"body-empty":             never(config.LevelOff, nil),
+		"body-max-line-length":   always(config.LevelWarning, 100),
+		"footer-format":          always(config.LevelError, nil),
+		"breaking-change-footer": always(config.LevelError, nil),
+	}
+}
+
+// Verbs whose past tense, gerund or third person form ends like the
+// suffixes below by coincidence
+var imperativeExceptions = []string{
+	"embed", "bleed", "breed", "feed", "need", "seed", "speed", "proceed", "exceed", "succeed",
+	"alias", "canvas",
+}
+
+// isImperative guesses from the first word whether a subject is in the
+// imperative mood: "added", "adding" and "adds" are not.
+func isImperative(word string) bool {
+	word = strings.ToLower(word)
+	if slices.Contains(imperativeExceptions, word) {
+		return true
+	}
+
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		return false
+	case len(word) > 5 && strings.HasSuffix(word, "ing") && hasVowel(strings.TrimSuffix(word, "ing")):
+		// "spring" and "string" have no vowel before the suffix, unlike a
+		// gerund's stem
+		return false
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return false
+	}
+	return true
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+func constant(s string) func(any) string {
+	return func(any) string { return s }
+}
+
+func toInt(v any) int {
+	n, _ := v.(int)
+	return n
+}
+
+func toString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func toStrings(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func isInt(v any) error {
+	if _, ok := v.(int); !ok {
+		return fmt.Errorf("value must be a number")
+	}
+	return nil
+}
+
+func isString(v any) error {
+	if _, ok := v.(string); !ok {
+		return fmt.Errorf("value must be a string")
+	}
+	return nil
+}
+
+func isStrings(v any) error {
+	switch v := v.(type) {
+	case nil, []string:
+		return nil
+	case []any:
+		for _, s := range v {
+			if _, ok := s.(string); !ok {
+				return fmt.Errorf("value must be a list of strings")
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("value must be a list of strings")
+}
@@ -0,0 +1,182 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"gcm/internal/config"
+	"gcm/internal/model"
+)
+
+func TestIsImperative(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"add", true},
+		{"Add", true},
+		{"added", false},
+		{"Added", false},
+		{"adding", false},
+		{"adds", false},
+		{"fixes", false},
+		{"trying", false},
+		{"updated", false},
+		{"use", true},
+		{"fix", true},
+		{"bleed", true},
+		{"feed", true},
+		{"speed", true},
+		{"embed", true},
+		{"spring", true},
+		{"string", true},
+		{"bring", true},
+		{"springs", false},
+		{"process", true},
+		{"focus", true},
+		{"alias", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := isImperative(tt.word); got != tt.want {
+				t.Errorf("isImperative = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   *config.TitleRules
+		rules   map[string]config.RuleSetting
+		scopes  []string
+		message string
+		targets []string
+		want    []string
+	}{
+		{name: "clean", message: "fix(cli): handle empty input"},
+		{
+			name:    "never",
+			message: "fix: handle empty input.",
+			want:    []string{"error: subject must not end with '.' [subject-full-stop]"},
+		},
+		{
+			name:    "never turned into always",
+			rules:   map[string]config.RuleSetting{"subject-full-stop": {Level: config.LevelError, When: "always"}},
+			message: "fix: handle empty input",
+			want:    []string{"error: subject must end with '.' [subject-full-stop]"},
+		},
+		{
+			name:    "always kept",
+			rules:   map[string]config.RuleSetting{"subject-full-stop": {Level: config.LevelError, When: "always"}},
+			message: "fix: handle empty input.",
+		},
+		{
+			name:    "always turned into never",
+			rules:   map[string]config.RuleSetting{"body-empty": {Level: config.LevelError, When: "never"}},
+			message: "fix: handle empty input",
+			want:    []string{"error: body must not be empty [body-empty]"},
+		},
+		{
+			name:    "level only",
+			rules:   map[string]config.RuleSetting{"body-empty": {Level: config.LevelWarning, When: "always"}},
+			message: "fix: handle empty input\n\nThe parser read past the end.",
+			want:    []string{"warning: body must be empty [body-empty]"},
+		},
+		{
+			name:    "level keeps the configured length",
+			title:   &config.TitleRules{MinLength: 10, MaxLength: 20},
+			rules:   map[string]config.RuleSetting{"subject-max-length": {Level: config.LevelWarning, When: "always"}},
+			message: "fix: handle input that is empty",
+			want:    []string{"warning: subject must be at most 20 characters (is 26) [subject-max-length]"},
+		},
+		{
+			name:    "value replaces the configured length",
+			title:   &config.TitleRules{MinLength: 10, MaxLength: 20},
+			rules:   map[string]config.RuleSetting{"subject-max-length": {Level: config.LevelError, When: "always", Value: 25}},
+			message: "fix: handle input that is empty",
+			want:    []string{"error: subject must be at most 25 characters (is 26) [subject-max-length]"},
+		},
+		{
+			name:    "level keeps the scope catalogue",
+			rules:   map[string]config.RuleSetting{"scope-enum": {Level: config.LevelError, When: "never"}},
+			scopes:  []string{"cli", "git"},
+			message: "fix(cli): handle empty input",
+			want:    []string{"error: scope must not be one of cli, git [scope-enum]"},
+		},
+		{
+			name:    "scope catalogue",
+			scopes:  []string{"cli", "git"},
+			message: "fix(ui): handle empty input",
+			want:    []string{"error: scope must be one of cli, git [scope-enum]"},
+		},
+		{
+			name:    "turned off",
+			rules:   map[string]config.RuleSetting{"subject-full-stop": {Level: config.LevelOff}},
+			message: "fix: handle empty input.",
+		},
+		{
+			name:    "errors first",
+			message: "wip: Handled empty input.",
+			want: []string{
+				"error: subject must be lower-case [subject-case]",
+				"error: subject must not end with '.' [subject-full-stop]",
+				"warning: type must be one of feat, fix, docs, style, refactor, perf, test, chore, build, ci [type-enum]",
+				"warning: subject must use the imperative mood, e.g. 'add' instead of 'added' or 'adds' (starts with 'Handled') [subject-imperative]",
+			},
+		},
+		{
+			name:    "targets",
+			message: "wip: Handled empty input.\n\nrefs #7",
+			targets: []string{"type", "footer"},
+			want:    []string{"warning: type must be one of feat, fix, docs, style, refactor, perf, test, chore, build, ci [type-enum]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			if tt.title != nil {
+				cfg.Title = *tt.title
+			}
+			cfg.Rules = tt.rules
+			engine, err := New(cfg, tt.scopes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, v := range engine.Lint(model.ParseMessage(tt.message), tt.targets...) {
+				got = append(got, fmt.Sprintf("%s: %s", v.Severity, v))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Lint =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules map[string]config.RuleSetting
+	}{
+		{name: "unknown rule", rules: map[string]config.RuleSetting{"subject-exclamation": {Level: config.LevelError}}},
+		{name: "wrong value", rules: map[string]config.RuleSetting{"subject-max-length": {Level: config.LevelError, When: "always", Value: "72"}}},
+		{name: "unknown case", rules: map[string]config.RuleSetting{"subject-case": {Level: config.LevelError, When: "always", Value: "camel-case"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Rules = tt.rules
+			if _, err := New(cfg, nil); err == nil {
+				t.Error("New succeeded, want an error")
+			}
+		})
+	}
+}
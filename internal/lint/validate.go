@@ -0,0 +1,63 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gcm/internal/config"
+)
+
+var (
+	scopePattern       = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*$`)
+	invalidBranchChars = regexp.MustCompile(`[~^:?*\[\]\\]`)
+)
+
+// ValidateScope checks a scope's characters and, with a non-empty
+// catalogue, that it is one of the configured scopes.
+func ValidateScope(scope string, catalogue []string) error {
+	if !scopePattern.MatchString(scope) {
+		return fmt.Errorf("scope must be lowercase letters, digits, '.', '_', '-' or '/'")
+	}
+
+	if len(catalogue) > 0 && !slices.Contains(catalogue, scope) {
+		return fmt.Errorf("scope '%s' is not in the configured list: %s", scope, strings.Join(catalogue, ", "))
+	}
+
+	return nil
+}
+
+// ValidateBranchName checks a new branch name against the configured
+// length and the characters git refuses in a ref.
+func ValidateBranchName(name string, rules config.BranchRules) error {
+	if name == "" {
+		return fmt.Errorf("branch name cannot be empty")
+	}
+
+	if len(name) > rules.MaxLength {
+		return fmt.Errorf("branch name too long (max %d characters)", rules.MaxLength)
+	}
+
+	// Check for spaces
+	if strings.Contains(name, " ") {
+		return fmt.Errorf("branch name cannot contain spaces")
+	}
+
+	// Check for invalid characters
+	if invalidBranchChars.MatchString(name) {
+		return fmt.Errorf("branch name contains invalid characters")
+	}
+
+	// Cannot start with dot or slash
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") {
+		return fmt.Errorf("branch name cannot start with '.' or '/'")
+	}
+
+	// Cannot end with slash or .lock
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".lock") {
+		return fmt.Errorf("invalid branch name ending")
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package lint
+
+import (
+	"testing"
+
+	"gcm/internal/config"
+)
+
+func TestValidateScope(t *testing.T) {
+	tests := []struct {
+		scope     string
+		catalogue []string
+		ok        bool
+	}{
+		{scope: "cli", ok: true},
+		{scope: "api/v2", ok: true},
+		{scope: "go.mod", ok: true},
+		{scope: ""},
+		{scope: "CLI"},
+		{scope: "-cli"},
+		{scope: "two words"},
+		{scope: "git", catalogue: []string{"cli", "git"}, ok: true},
+		{scope: "ui", catalogue: []string{"cli", "git"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			if err := ValidateScope(tt.scope, tt.catalogue); (err == nil) != tt.ok {
+				t.Errorf("ValidateScope = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateBranchName(t *testing.T) {
+	rules := config.BranchRules{MaxLength: 20}
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{name: "fix/12-input", ok: true},
+		{name: "feat/version.flag", ok: true},
+		{name: ""},
+		{name: "feat/a-very-long-branch-name"},
+		{name: "fix input"},
+		{name: "fix:input"},
+		{name: "fix/input~1"},
+		{name: "fix/[input]"},
+		{name: ".hidden"},
+		{name: "/fix"},
+		{name: "fix/"},
+		{name: "fix.lock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBranchName(tt.name, rules); (err == nil) != tt.ok {
+				t.Errorf("ValidateBranchName = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
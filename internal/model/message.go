@@ -0,0 +1,107 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+var headerLine = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\(([^()]*)\))?(!)?:[ \t]*(.*)$`)
+
+// scissors follows the comment character on the line below which git drops
+// the rest of the message, such as the diff `git commit -v` shows.
+const scissors = " ------------------------ >8 ------------------------"
+
+// CleanMessage does what git's default cleanup does to an edited message:
+// everything from the scissors line on and the lines starting with
+// commentChar are dropped, and trailing whitespace is trimmed. For
+// core.commentChar "auto" the character is taken from the scissors line,
+// falling back to '#'.
+func CleanMessage(raw, commentChar string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+
+	if commentChar == "auto" || commentChar == "" {
+		commentChar = "#"
+		for _, line := range lines {
+			if prefix, ok := strings.CutSuffix(line, scissors); ok && prefix != "" {
+				commentChar = prefix
+				break
+			}
+		}
+	}
+
+	var kept []string
+	for _, line := range lines {
+		if line == commentChar+scissors {
+			break
+		}
+		if !strings.HasPrefix(line, commentChar) {
+			kept = append(kept, strings.TrimRight(line, " \t"))
+		}
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n")
+}
+
+// ParseMessage splits a raw commit message into its parts, cleaned up with
+// the default comment character '#'. A header that isn't
+// "type(scope)!: title" ends up whole in Title with an empty Type, and the
+// last paragraph counts as footers only if every line is a trailer or a
+// continuation of one.
+func ParseMessage(raw string) CommitInfo {
+	return parseMessage(CleanMessage(raw, "#"))
+}
+
+// ParseEditedMessage parses a message as git would commit it with
+// core.commentChar set to commentChar, e.g. in a commit-msg hook.
+func ParseEditedMessage(raw, commentChar string) CommitInfo {
+	return parseMessage(CleanMessage(raw, commentChar))
+}
+
+func parseMessage(text string) CommitInfo {
+	header, rest, _ := strings.Cut(text, "\n")
+
+	var info CommitInfo
+	if m := headerLine.FindStringSubmatch(header); m != nil {
+		info.Type = m[1]
+		info.Scope = m[2]
+		info.Breaking = m[3] == "!"
+		info.Title = m[4]
+	} else {
+		info.Title = header
+	}
+
+	paragraphs := strings.Split(strings.Trim(rest, "\n"), "\n\n")
+	if last := len(paragraphs) - 1; last > 0 || paragraphs[0] != "" {
+		if trailers, ok := parseFooters(paragraphs[last]); ok {
+			for _, t := range trailers {
+				if IsBreakingToken(t.Token) {
+					info.BreakingChange = t.Value
+				} else {
+					info.Trailers = append(info.Trailers, t)
+				}
+			}
+			paragraphs = paragraphs[:last]
+		}
+	}
+	info.Description = strings.Trim(strings.Join(paragraphs, "\n\n"), "\n")
+
+	return info
+}
+
+// parseFooters parses a paragraph made only of trailers. Indented lines
+// continue the value of the previous trailer.
+func parseFooters(paragraph string) ([]Trailer, bool) {
+	var trailers []Trailer
+	for _, line := range strings.Split(paragraph, "\n") {
+		if n := len(trailers); n > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			trailers[n-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		t, ok := ParseTrailer(line)
+		if !ok {
+			return nil, false
+		}
+		trailers = append(trailers, t)
+	}
+	return trailers, len(trailers) > 0
+}
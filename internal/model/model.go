@@ -1,18 +1,67 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type GitChange struct {
 	Index   byte
 	Working byte
 	Path    string
+
+	// Populated from `git status --porcelain=v2` records.
+	OrigPath     string // source path of a rename or copy
+	Score        int    // similarity percentage of a rename or copy
+	Submodule    SubmoduleState
+	Unmerged     bool
+	ModeHead     string
+	ModeIndex    string
+	ModeWorktree string
+
+	// Patch, when set, is staged with `git apply --cached` instead of
+	// adding the whole path.
+	Patch string
+
+	// Stat is the size of the change, filled in from Repository.DiffStats.
+	Stat DiffStat
+}
+
+// DiffStat counts the lines a change adds and removes, like
+// `git diff --numstat`, and the size of its file.
+type DiffStat struct {
+	Added   int
+	Removed int
+	Binary  bool
+	Size    int64 // bytes in the working tree, 0 once deleted
+}
+
+type SubmoduleState struct {
+	IsSubmodule   bool
+	CommitChanged bool
+	Modified      bool
+	Untracked     bool
 }
 
 func (g GitChange) StatusKey() string {
 	return string([]byte{g.Index, g.Working})
 }
 
+// IsStaged reports whether the entry has changes in the index.
+func (g GitChange) IsStaged() bool {
+	return !g.Unmerged && g.Index != ' ' && g.Index != '?'
+}
+
+// CanStagePartially reports whether the working tree changes of g can be
+// split into hunks with `git diff`.
+func (g GitChange) CanStagePartially() bool {
+	return g.Working == 'M' && !g.Submodule.IsSubmodule
+}
+
 func (g GitChange) DisplayLabel() string {
+	if g.OrigPath != "" {
+		return fmt.Sprintf("[%c%c] %s -> %s", g.Index, g.Working, g.OrigPath, g.Path)
+	}
 	return fmt.Sprintf("[%c%c] %s", g.Index, g.Working, g.Path)
 }
 
@@ -26,7 +75,7 @@ func (g GitChange) DisplayType() string {
 		return "DELETED"
 	case status == "A " || status == " A":
 		return "ADDED"
-	case status[0] == 'R':
+	case status[0] == 'R' || status[0] == 'C':
 		return "RENAMED"
 	case status == "??":
 		return "UNTRACKED"
@@ -36,8 +85,8 @@ func (g GitChange) DisplayType() string {
 }
 
 type CommitType struct {
-	Key         string
-	Description string
+	Key         string `yaml:"key"`
+	Description string `yaml:"description"`
 }
 
 var CommitTypes = []CommitType{
@@ -55,13 +104,64 @@ var CommitTypes = []CommitType{
 
 type CommitInfo struct {
 	Type        string
+	Scope       string
 	Title       string
 	Description string
+
+	// Breaking adds the "!" marker; BreakingChange is the text of the
+	// "BREAKING CHANGE:" footer that has to go with it.
+	Breaking       bool
+	BreakingChange string
+
+	Trailers []Trailer
+}
+
+// Prefix renders "type(scope)!", leaving out the scope and marker when
+// they are not set.
+func (c CommitInfo) Prefix() string {
+	prefix := c.Type
+	if c.Scope != "" {
+		prefix = fmt.Sprintf("%s(%s)", c.Type, c.Scope)
+	}
+	if c.Breaking {
+		prefix += "!"
+	}
+	return prefix
+}
+
+// Header renders the first line of the message, "type(scope): title".
+func (c CommitInfo) Header() string {
+	return fmt.Sprintf("%s: %s", c.Prefix(), c.Title)
+}
+
+// Footers renders the footer paragraph of the message: the breaking
+// change note followed by the trailers, one per line and without repeating
+// identical ones, the way `git interpret-trailers` lays them out.
+func (c CommitInfo) Footers() string {
+	var lines []string
+	if c.BreakingChange != "" {
+		lines = append(lines, Trailer{TrailerBreaking, c.BreakingChange}.String())
+	}
+
+	seen := make(map[Trailer]bool)
+	for _, t := range c.Trailers {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		lines = append(lines, t.String())
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func (c CommitInfo) FullMessage() string {
+	msg := c.Header()
 	if c.Description != "" {
-		return fmt.Sprintf("%s: %s\n\n%s", c.Type, c.Title, c.Description)
+		msg = fmt.Sprintf("%s\n\n%s", msg, c.Description)
+	}
+	if footers := c.Footers(); footers != "" {
+		msg = fmt.Sprintf("%s\n\n%s", msg, footers)
 	}
-	return fmt.Sprintf("%s: %s", c.Type, c.Title)
+	return msg
 }
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Trailer is a "Token: value" line in the footer paragraph of a commit
+// message, as understood by `git interpret-trailers`.
+type Trailer struct {
+	Token string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return fmt.Sprintf("%s: %s", t.Token, t.Value)
+}
+
+// Common trailer tokens
+const (
+	TrailerRefs           = "Refs"
+	TrailerCloses         = "Closes"
+	TrailerCoAuthor       = "Co-authored-by"
+	TrailerSignedOff      = "Signed-off-by"
+	TrailerBreaking       = "BREAKING CHANGE"
+	trailerBreakingHyphen = "BREAKING-CHANGE"
+)
+
+var (
+	trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*|BREAKING CHANGE): (.+)$`)
+	identity    = regexp.MustCompile(`^[^<>]+ <[^<>\s@]+@[^<>\s]+>$`)
+
+	// An issue key or number starting the description of a type/description
+	// branch name
+	branchIssue = regexp.MustCompile(`^([a-z]+)/(?:(([A-Z][A-Z0-9]+)-[0-9]+)|#?([0-9]+))(?:[/_-]|$)`)
+
+	// Names shaped like issue keys that are standards, like UTF-8
+	notIssueKeys = []string{"AES", "CRC", "HTTP", "ISO", "MD5", "RFC", "RSA", "SHA", "TLS", "UTF"}
+)
+
+// ParseTrailer parses a single footer line.
+func ParseTrailer(line string) (Trailer, bool) {
+	m := trailerLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Trailer{}, false
+	}
+	return Trailer{Token: m[1], Value: strings.TrimSpace(m[2])}, true
+}
+
+// IsBreakingToken reports whether token is one of the two spellings of the
+// breaking change footer.
+func IsBreakingToken(token string) bool {
+	return token == TrailerBreaking || token == trailerBreakingHyphen
+}
+
+// ValidateTrailer checks the token format and, for identity trailers, that
+// the value looks like "Name <email>".
+func ValidateTrailer(t Trailer) error {
+	if _, ok := ParseTrailer(t.String()); !ok || strings.Contains(t.Value, "\n") {
+		return fmt.Errorf("trailer must look like 'Token: value', got %q", t.String())
+	}
+
+	switch t.Token {
+	case TrailerCoAuthor, TrailerSignedOff:
+		if !identity.MatchString(t.Value) {
+			return fmt.Errorf("%s must look like 'Name <email>'", t.Token)
+		}
+	}
+
+	return nil
+}
+
+// IssueRefsFromBranch infers issue references from a branch name named
+// after one of types, e.g. "feat/PROJ-42-login" gives "PROJ-42" and
+// "fix/123-crash" gives "#123". Only the start of the description counts,
+// so "release/2024-q3", "feat/add-3-retries" and "feat/UTF-8-names" give
+// nothing.
+func IssueRefsFromBranch(branch string, types []CommitType) []string {
+	m := branchIssue.FindStringSubmatch(branch)
+	if m == nil || !slices.ContainsFunc(types, func(t CommitType) bool { return t.Key == m[1] }) {
+		return nil
+	}
+
+	switch {
+	case m[2] != "" && !slices.Contains(notIssueKeys, m[3]):
+		return []string{m[2]}
+	case m[4] != "":
+		return []string{"#" + m[4]}
+	}
+	return nil
+}
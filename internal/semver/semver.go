@@ -0,0 +1,141 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionPattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z.-]+))?$`)
+
+// Version is a semantic version such as v1.4.0 or v2.0.0-rc.1.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string // pre-release, e.g. "rc.1"
+}
+
+// Parse reads "vX.Y.Z[-pre]"; the "v" is optional.
+func Parse(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	v.Pre = m[4]
+	return v, nil
+}
+
+// String renders the version with a "v" prefix, as gcm names tags.
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare orders versions by precedence: -1, 0 or 1. A pre-release comes
+// before its release.
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	}
+	return comparePre(a.Pre, b.Pre)
+}
+
+// comparePre compares dot-separated pre-release identifiers, numeric ones
+// numerically.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Latest returns the highest of the names that are semantic versions.
+// With stable, pre-releases are skipped.
+func Latest(names []string, stable bool) (string, Version, bool) {
+	var best string
+	var bestVersion Version
+	found := false
+
+	for _, name := range names {
+		v, err := Parse(name)
+		if err != nil || stable && v.Pre != "" {
+			continue
+		}
+		if !found || Compare(v, bestVersion) > 0 {
+			best, bestVersion, found = name, v, true
+		}
+	}
+	return best, bestVersion, found
+}
+
+// Level is the part of a version a set of changes bumps.
+type Level int
+
+const (
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+func (l Level) String() string {
+	return [...]string{"none", "patch", "minor", "major"}[l]
+}
+
+// Bump returns the next release at level, dropping any pre-release.
+func (v Version) Bump(level Level) Version {
+	switch level {
+	case Major:
+		return Version{Major: v.Major + 1}
+	case Minor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
@@ -0,0 +1,113 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		ok   bool
+	}{
+		{in: "v1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{in: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}, ok: true},
+		{in: "v2.0.0-rc.1", want: Version{Major: 2, Pre: "rc.1"}, ok: true},
+		{in: "v1.2"},
+		{in: "v01.2.3"},
+		{in: "release-1.2.3"},
+		{in: "v1.2.3-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("Parse = %+v, %v; want %+v, ok %v", got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.3.0", "v1.2.9", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.2.0-rc.1", "v1.2.0", -1},
+		{"v1.2.0", "v1.2.0-rc.1", 1},
+		{"v1.2.0-rc.2", "v1.2.0-rc.10", -1},
+		{"v1.2.0-alpha", "v1.2.0-beta", -1},
+		{"v1.2.0-rc", "v1.2.0-rc.1", -1},
+		{"v1.2.0-1", "v1.2.0-rc", -1},
+		{"v1.2.0-rc.1", "v1.1.0", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := Parse(tt.a)
+			b, _ := Parse(tt.b)
+			if got := Compare(a, b); got != tt.want {
+				t.Errorf("Compare = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatest(t *testing.T) {
+	names := []string{"v1.2.0", "latest", "v1.10.0-rc.1", "v1.9.0", "v1.10.0-rc.2", "v1.3.0"}
+
+	tests := []struct {
+		name   string
+		names  []string
+		stable bool
+		want   string
+	}{
+		{name: "none", names: nil},
+		{name: "no versions", names: []string{"latest", "nightly"}},
+		{name: "any", names: names, want: "v1.10.0-rc.2"},
+		{name: "stable", names: names, stable: true, want: "v1.9.0"},
+		{name: "only pre-releases", names: []string{"v1.0.0-rc.1"}, stable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, v, found := Latest(tt.names, tt.stable)
+			if got != tt.want || found != (tt.want != "") {
+				t.Errorf("Latest = %q, %v; want %q", got, found, tt.want)
+			}
+			if found && v.String() != tt.want {
+				t.Errorf("version = %s, want %s", v, tt.want)
+			}
+		})
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		version string
+		level   Level
+		want    string
+	}{
+		{"v1.2.3", None, "v1.2.3"},
+		{"v1.2.3", Patch, "v1.2.4"},
+		{"v1.2.3", Minor, "v1.3.0"},
+		{"v1.2.3", Major, "v2.0.0"},
+		{"v0.0.0", Minor, "v0.1.0"},
+		{"v1.3.0-rc.2", None, "v1.3.0"},
+		{"v1.3.0-rc.2", Patch, "v1.3.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.level.String(), func(t *testing.T) {
+			v, _ := Parse(tt.version)
+			if got := v.Bump(tt.level).String(); got != tt.want {
+				t.Errorf("Bump = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
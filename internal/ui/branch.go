@@ -2,9 +2,11 @@ package ui
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
+	"gcm/internal/config"
+	"gcm/internal/lint"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -23,6 +25,9 @@ var (
 
 	infoStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("86"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("226"))
 )
 
 type BranchModel struct {
@@ -35,19 +40,30 @@ type BranchModel struct {
 	quitting      bool
 	confirmed     bool
 	newBranch     string
+	rules         config.BranchRules
+
+	view viewport
 }
 
-func NewBranchModel(currentBranch string, isMainBranch bool) *BranchModel {
+// NewBranchModel asks whether to stay on currentBranch or create one.
+// chosen is the answer of an earlier visit, if any.
+func NewBranchModel(currentBranch, chosen string, isMainBranch bool, rules config.BranchRules) *BranchModel {
 	mode := "input"
-	if !isMainBranch {
+	if !isMainBranch && (chosen == "" || chosen == currentBranch) {
 		mode = "confirm"
 	}
 
+	input := ""
+	if chosen != currentBranch {
+		input = chosen
+	}
+
 	return &BranchModel{
 		currentBranch: currentBranch,
 		isMainBranch:  isMainBranch,
 		mode:          mode,
-		input:         "",
+		input:         input,
+		rules:         rules,
 	}
 }
 
@@ -59,7 +75,17 @@ func (m *BranchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "esc":
+		case "esc", "shift+tab":
+			if m.mode == "input" && !m.isMainBranch {
+				// Back to staying on the current branch
+				m.mode = "confirm"
+				m.err = ""
+				return m, nil
+			}
+			m.quitting = true
+			return m, tea.Quit
+
+		case "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
 
@@ -72,7 +98,7 @@ func (m *BranchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			} else if m.mode == "input" {
 				// Validate branch name
-				if err := validateBranchName(m.input); err != nil {
+				if err := lint.ValidateBranchName(m.input, m.rules); err != nil {
 					m.err = err.Error()
 					return m, nil
 				}
@@ -109,6 +135,9 @@ func (m *BranchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = ""
 			}
 		}
+
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
 	}
 
 	return m, nil
@@ -120,15 +149,20 @@ func (m *BranchModel) View() string {
 	}
 
 	var b strings.Builder
+	width := m.view.width
+	// Branch names are cut at the start, as their end is the description
+	branch := func(used string) string {
+		return truncatePath(m.currentBranch, budget(width, used))
+	}
 
 	if m.isMainBranch {
 		b.WriteString(titleStyle.Render("⚠️  Branch Management") + "\n\n")
-		b.WriteString(errorStyle.Render(fmt.Sprintf("Cannot commit directly to '%s'.", m.currentBranch)) + "\n")
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Cannot commit directly to '%s'.", branch("Cannot commit directly to ''."))) + "\n")
 		b.WriteString("Please create a new branch:\n\n")
 	} else if m.mode == "confirm" {
 		b.WriteString(titleStyle.Render("📌 Branch Management") + "\n\n")
-		b.WriteString(fmt.Sprintf("Current branch: %s\n\n", infoStyle.Render(m.currentBranch)))
-		b.WriteString(fmt.Sprintf("Use current branch '%s'? (y/n): ", m.currentBranch))
+		b.WriteString(fmt.Sprintf("Current branch: %s\n\n", infoStyle.Render(branch("Current branch: "))))
+		b.WriteString(fmt.Sprintf("Use current branch '%s'? (y/n): ", branch("Use current branch ''? (y/n): ")))
 		return b.String()
 	} else {
 		b.WriteString(titleStyle.Render("📌 Create New Branch") + "\n\n")
@@ -136,57 +170,27 @@ func (m *BranchModel) View() string {
 
 	if m.mode == "input" {
 		b.WriteString("Branch name: ")
-		b.WriteString(m.input)
+		b.WriteString(truncatePath(m.input, budget(width, "Branch name: _")))
 		b.WriteString("_\n\n")
 
 		if m.err != "" {
-			b.WriteString(errorStyle.Render("❌ "+m.err) + "\n\n")
+			b.WriteString(errorStyle.Render(truncate("❌ "+m.err, width)) + "\n\n")
 		}
 
-		b.WriteString(promptStyle.Render("Suggested format: type/short-description\n"))
-		b.WriteString(promptStyle.Render("Examples: feat/login, fix/button-crash, chore/deps\n"))
-		b.WriteString(promptStyle.Render("Press Enter to confirm, Esc to cancel\n"))
+		b.WriteString(promptStyle.Render(truncate("Suggested format: type/short-description", width)) + "\n")
+		b.WriteString(promptStyle.Render(truncate("Examples: feat/login, fix/button-crash, chore/deps", width)) + "\n")
+		if m.isMainBranch {
+			b.WriteString(promptStyle.Render(truncate("Press Enter to confirm, Esc to cancel", width)) + "\n")
+		} else {
+			b.WriteString(promptStyle.Render(truncate("Press Enter to confirm, Esc to go back", width)) + "\n")
+		}
 	}
 
 	return b.String()
 }
 
-func validateBranchName(name string) error {
-	if name == "" {
-		return fmt.Errorf("branch name cannot be empty")
-	}
-
-	if len(name) > 50 {
-		return fmt.Errorf("branch name too long (max 50 characters)")
-	}
-
-	// Check for spaces
-	if strings.Contains(name, " ") {
-		return fmt.Errorf("branch name cannot contain spaces")
-	}
-
-	// Check for invalid characters
-	invalidChars := regexp.MustCompile(`[~^:?*\[\]\\]`)
-	if invalidChars.MatchString(name) {
-		return fmt.Errorf("branch name contains invalid characters")
-	}
-
-	// Cannot start with dot or slash
-	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "/") {
-		return fmt.Errorf("branch name cannot start with '.' or '/'")
-	}
-
-	// Cannot end with slash or .lock
-	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".lock") {
-		return fmt.Errorf("invalid branch name ending")
-	}
-
-	return nil
-}
-
-func RunBranchSelection(currentBranch string, isMainBranch bool) (string, bool, error) {
-	p := tea.NewProgram(NewBranchModel(currentBranch, isMainBranch))
-	m, err := p.Run()
+func (s *Screen) RunBranchSelection(currentBranch, chosen string, isMainBranch bool, rules config.BranchRules) (string, bool, error) {
+	m, err := s.run(NewBranchModel(currentBranch, chosen, isMainBranch, rules))
 	if err != nil {
 		return "", false, err
 	}
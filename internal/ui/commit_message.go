@@ -2,27 +2,72 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
-	"unicode"
+
+	"gcm/internal/config"
+	"gcm/internal/lint"
+	"gcm/internal/model"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// bodyWidth is where commit message bodies wrap.
+const bodyWidth = 72
+
 type CommitMessageModel struct {
-	commitType  string
-	title       string
-	description string
-	mode        string // "title" or "description" or "preview"
-	err         string
-	quitting    bool
-	confirmed   bool
+	info         model.CommitInfo
+	title        string
+	description  *TextArea
+	breakingNote string
+	mode         string // "title", "description", "breaking", "trailers" or "preview"
+	quitting     bool
+	confirmed    bool
+	back         bool
+	rules        config.TitleRules
+	linter       *lint.Engine
+	violations   []lint.Violation
+
+	// Trailers step
+	suggestions   TrailerSuggestions
+	trailers      []model.Trailer
+	trailerCursor int
+	trailerToken  string // token being added, empty while browsing
+	trailerInput  string
+	trailerPick   int
+	trailerErr    string
+
+	view viewport
 }
 
-func NewCommitMessageModel(commitType string) *CommitMessageModel {
+func NewCommitMessageModel(info model.CommitInfo, suggestions TrailerSuggestions, rules config.TitleRules, linter *lint.Engine) *CommitMessageModel {
+	trailers := slices.Clone(info.Trailers)
+
 	return &CommitMessageModel{
-		commitType: commitType,
-		mode:       "title",
+		info:         info,
+		title:        info.Title,
+		description:  NewTextArea(info.Description, bodyWidth),
+		breakingNote: info.BreakingChange,
+		mode:         "title",
+		rules:        rules,
+		linter:       linter,
+		suggestions:  suggestions,
+		trailers:     trailers,
+	}
+}
+
+// commitInfo returns the message as entered so far.
+func (m *CommitMessageModel) commitInfo() model.CommitInfo {
+	info := m.info
+	info.Title = strings.TrimSpace(m.title)
+	info.Description = strings.TrimSpace(m.description.Value())
+	info.BreakingChange = ""
+	if info.Breaking {
+		info.BreakingChange = strings.TrimSpace(m.breakingNote)
 	}
+	info.Trailers = m.trailers
+	return info
 }
 
 func (m *CommitMessageModel) Init() tea.Cmd {
@@ -37,82 +82,136 @@ func (m *CommitMessageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 
-		case "esc":
-			if m.mode == "description" || m.mode == "preview" {
-				m.mode = "title"
-				m.err = ""
-			} else {
-				m.quitting = true
-				return m, tea.Quit
-			}
+		case "ctrl+b":
+			// Toggle the breaking change marker from any step
+			m.info.Breaking = !m.info.Breaking
+			return m, nil
 
-		case "enter":
-			if m.mode == "title" {
-				if err := validateTitle(m.title); err != nil {
-					m.err = err.Error()
-					return m, nil
-				}
-				m.mode = "description"
-				m.err = ""
-			} else if m.mode == "description" {
-				// Empty enter skips description
-				m.mode = "preview"
-				m.err = ""
-			} else if m.mode == "preview" {
-				// Confirm
-				m.confirmed = true
-				m.quitting = true
-				return m, tea.Quit
-			}
+		case "shift+tab":
+			// Same as Esc: one step back
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
 
-		case "ctrl+d":
-			if m.mode == "description" {
-				m.mode = "preview"
-				m.err = ""
-			}
+		switch m.mode {
+		case "title":
+			return m.updateTitle(msg)
+		case "description":
+			return m.updateDescription(msg)
+		case "breaking":
+			return m.updateBreaking(msg)
+		case "trailers":
+			return m.updateTrailers(msg)
+		case "preview":
+			return m.updatePreview(msg)
+		}
 
-		case "backspace":
-			if m.mode == "title" && len(m.title) > 0 {
-				m.title = m.title[:len(m.title)-1]
-				m.err = ""
-			} else if m.mode == "description" && len(m.description) > 0 {
-				m.description = m.description[:len(m.description)-1]
-			}
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
+	}
 
-		case "y":
-			if m.mode == "preview" {
-				m.confirmed = true
-				m.quitting = true
-				return m, tea.Quit
-			}
+	return m, nil
+}
 
-		case "n":
-			if m.mode == "preview" {
-				m.mode = "title"
-				m.err = ""
-			}
+func (m *CommitMessageModel) updateTitle(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.back = true
+		m.quitting = true
+		return m, tea.Quit
+
+	case "enter":
+		m.violations = m.linter.Lint(m.commitInfo(), lint.HeaderTargets...)
+		if lint.HasErrors(m.violations) {
+			return m, nil
+		}
+		m.mode = "description"
 
-		case "e":
-			if m.mode == "preview" {
-				m.mode = "title"
-				m.err = ""
-			}
+	case "backspace":
+		if len(m.title) > 0 {
+			m.title = m.title[:len(m.title)-1]
+			m.violations = nil
+		}
 
-		default:
-			if m.mode == "title" && len(msg.String()) == 1 {
-				m.title += msg.String()
-				m.err = ""
-			} else if m.mode == "description" {
-				if len(msg.String()) == 1 {
-					m.description += msg.String()
-				}
-			}
+	default:
+		if len(msg.String()) == 1 {
+			m.title += msg.String()
+			m.violations = nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m *CommitMessageModel) updateDescription(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = "title"
+
+	case "enter", "ctrl+d":
+		// Enter adds a line unless the description is still empty
+		if msg.String() == "enter" && !m.description.Empty() {
+			m.description.Update(msg)
+			break
+		}
+		m.mode = "trailers"
+		if m.info.Breaking {
+			m.mode = "breaking"
+		}
+
+	default:
+		m.description.Update(msg)
+	}
+
+	return m, nil
+}
+
+func (m *CommitMessageModel) updateBreaking(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = "description"
+		m.violations = nil
+
+	case "enter":
+		m.violations = m.linter.Lint(m.commitInfo(), "footer")
+		if lint.HasErrors(m.violations) {
+			return m, nil
+		}
+		m.mode = "trailers"
+		m.violations = nil
+
+	case "backspace":
+		if len(m.breakingNote) > 0 {
+			m.breakingNote = m.breakingNote[:len(m.breakingNote)-1]
+			m.violations = nil
+		}
+
+	default:
+		if len(msg.String()) == 1 {
+			m.breakingNote += msg.String()
+			m.violations = nil
 		}
 	}
 
 	return m, nil
 }
 
+func (m *CommitMessageModel) updatePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "y":
+		if lint.HasErrors(m.linter.Lint(m.commitInfo())) {
+			return m, nil
+		}
+		m.confirmed = true
+		m.quitting = true
+		return m, tea.Quit
+
+	case "esc", "n", "e":
+		m.mode = "title"
+	}
+
+	return m, nil
+}
+
 func (m *CommitMessageModel) View() string {
 	if m.quitting && m.confirmed {
 		return ""
@@ -122,88 +221,128 @@ func (m *CommitMessageModel) View() string {
 
 	if m.mode == "title" {
 		b.WriteString(titleStyle.Render("📝 Commit Title") + "\n\n")
-		b.WriteString(fmt.Sprintf("Type: %s\n\n", infoStyle.Render(m.commitType)))
+		b.WriteString(fmt.Sprintf("Type: %s\n\n", infoStyle.Render(m.info.Prefix())))
 		b.WriteString("Commit title (required):\n")
 		b.WriteString("> " + m.title + "_\n\n")
-
-		if m.err != "" {
-			b.WriteString(errorStyle.Render("❌ "+m.err) + "\n\n")
-		}
+		b.WriteString(renderViolations(m.violations))
 
 		titleLen := len(m.title)
-		if titleLen > 50 && titleLen <= 72 {
-			b.WriteString(promptStyle.Render(fmt.Sprintf("⚠️  Warning: Title is %d characters (recommended max: 50)\n", titleLen)))
+		if m.rules.WarnLength > 0 && titleLen > m.rules.WarnLength && titleLen <= m.rules.MaxLength {
+			b.WriteString(promptStyle.Render(fmt.Sprintf("⚠️  Warning: Title is %d characters (recommended max: %d)", titleLen, m.rules.WarnLength)) + "\n")
 		}
 
-		b.WriteString(promptStyle.Render("Rules:\n"))
-		b.WriteString(promptStyle.Render("  - Min 10 characters, max 72\n"))
-		b.WriteString(promptStyle.Render("  - First letter lowercase (conventional commits)\n"))
-		b.WriteString(promptStyle.Render("  - No period at the end\n"))
-		b.WriteString(promptStyle.Render("  - Concise and clear description\n\n"))
-		b.WriteString(promptStyle.Render("Example: \"add email validation in registration form\"\n"))
-		b.WriteString(promptStyle.Render("Press Enter to continue\n"))
+		b.WriteString(promptStyle.Render("Rules:") + "\n")
+		b.WriteString(promptStyle.Render(fmt.Sprintf("  - Min %d characters, max %d", m.rules.MinLength, m.rules.MaxLength)) + "\n")
+		switch m.rules.Case {
+		case config.CaseLower:
+			b.WriteString(promptStyle.Render("  - First letter lowercase (conventional commits)") + "\n")
+		case config.CaseUpper:
+			b.WriteString(promptStyle.Render("  - First letter uppercase") + "\n")
+		}
+		if !m.rules.AllowPeriod {
+			b.WriteString(promptStyle.Render("  - No period at the end") + "\n")
+		}
+		b.WriteString(promptStyle.Render("  - Concise and clear description") + "\n\n")
+		b.WriteString(promptStyle.Render("Example: \"add email validation in registration form\"") + "\n")
+		b.WriteString(m.breakingStatus())
+		b.WriteString(promptStyle.Render("Press Enter to continue, Esc to go back") + "\n")
 
 	} else if m.mode == "description" {
 		b.WriteString(titleStyle.Render("📝 Detailed Description (Optional)") + "\n\n")
-		b.WriteString(fmt.Sprintf("%s: %s\n\n", m.commitType, m.title))
+		b.WriteString(m.commitInfo().Header() + "\n\n")
 		b.WriteString("Description:\n")
 
-		if m.description == "" {
-			b.WriteString(promptStyle.Render("(Press Enter to skip, or type description)\n\n"))
-		} else {
-			b.WriteString(m.description + "_\n\n")
+		if m.description.Empty() {
+			b.WriteString(promptStyle.Render("(Press Enter to skip, or type description)") + "\n")
 		}
+		b.WriteString(m.description.View() + "\n")
+
+		b.WriteString(promptStyle.Render(fmt.Sprintf("Tip: Explain the 'why', not the 'what' (that's in the diff). Lines wrap at %d columns", bodyWidth)) + "\n")
+		b.WriteString(promptStyle.Render("Enter new line · arrows/Home/End move · Alt+←/→ word · Ctrl+W delete word · Ctrl+Z undo") + "\n")
+		b.WriteString(m.breakingStatus())
+		b.WriteString(promptStyle.Render("Press Ctrl+D (or Enter while empty) to finish, Esc to go back") + "\n")
+
+	} else if m.mode == "breaking" {
+		b.WriteString(titleStyle.Render("💥 Breaking Change") + "\n\n")
+		b.WriteString(m.commitInfo().Header() + "\n\n")
+		b.WriteString("BREAKING CHANGE: " + m.breakingNote + "_\n\n")
+		b.WriteString(renderViolations(m.violations))
+
+		b.WriteString(promptStyle.Render("Describe what breaks and how to migrate; release tooling bumps the major version") + "\n")
+		b.WriteString(promptStyle.Render("Press Enter to continue, Ctrl+B to drop the breaking marker, Esc to go back") + "\n")
 
-		b.WriteString(promptStyle.Render("Tip: Explain the 'why', not the 'what' (that's in the diff)\n"))
-		b.WriteString(promptStyle.Render("Press Ctrl+D or Enter (empty) to finish, Esc to go back\n"))
+	} else if m.mode == "trailers" {
+		m.viewTrailers(&b)
 
 	} else if m.mode == "preview" {
 		b.WriteString(titleStyle.Render("📋 Commit Preview") + "\n\n")
-		b.WriteString("+" + strings.Repeat("-", 70) + "+\n")
-		b.WriteString("|" + strings.Repeat(" ", 70) + "|\n")
-		b.WriteString(fmt.Sprintf("|  %s: %-63s|\n", m.commitType, m.title))
-		b.WriteString("|" + strings.Repeat(" ", 70) + "|\n")
-
-		if m.description != "" {
-			// Split description into lines
-			descLines := splitIntoLines(m.description, 66)
-			for _, line := range descLines {
-				b.WriteString(fmt.Sprintf("|  %-68s|\n", line))
+		info := m.commitInfo()
+
+		// The box fits 72-column bodies, narrowing on small terminals
+		width := bodyWidth
+		if m.view.width > 0 {
+			width = max(min(bodyWidth, m.view.width-6), 20)
+		}
+		inner := width + 4
+		border := "+" + strings.Repeat("-", inner) + "+\n"
+		blank := "|" + strings.Repeat(" ", inner) + "|\n"
+		row := func(text string) {
+			// Lines longer than the box, like long titles, wrap on screen only
+			lines := []string{text}
+			if lipgloss.Width(text) > width {
+				lines = splitIntoLines(text, width)
+			}
+			for _, line := range lines {
+				pad := max(width-lipgloss.Width(line), 0)
+				b.WriteString("|  " + line + strings.Repeat(" ", pad) + "  |\n")
 			}
-			b.WriteString("|" + strings.Repeat(" ", 70) + "|\n")
 		}
 
-		b.WriteString("+" + strings.Repeat("-", 70) + "+\n\n")
-		b.WriteString("Confirm commit message? (y/n/e to edit): ")
-	}
+		b.WriteString(border)
+		b.WriteString(blank)
+		row(info.Header())
+		b.WriteString(blank)
 
-	return b.String()
-}
+		if info.Description != "" {
+			for _, line := range strings.Split(info.Description, "\n") {
+				row(line)
+			}
+			b.WriteString(blank)
+		}
 
-func validateTitle(title string) error {
-	title = strings.TrimSpace(title)
+		if footers := info.Footers(); footers != "" {
+			// One footer per line, each wrapped on its own
+			for _, footer := range strings.Split(footers, "\n") {
+				row(footer)
+			}
+			b.WriteString(blank)
+		}
 
-	if title == "" {
-		return fmt.Errorf("title cannot be empty")
-	}
+		b.WriteString(border + "\n")
+		b.WriteString(renderViolations(m.linter.Lint(m.commitInfo())))
 
-	if len(title) < 10 {
-		return fmt.Errorf("title too short (min 10 characters)")
+		b.WriteString("Confirm commit message? (y/n/e to edit, Ctrl+B to toggle breaking): ")
 	}
 
-	if len(title) > 72 {
-		return fmt.Errorf("title too long (max 72 characters)")
-	}
+	return b.String()
+}
 
-	if strings.HasSuffix(title, ".") {
-		return fmt.Errorf("title should not end with a period")
+// renderViolations lists every lint violation, errors first.
+func renderViolations(violations []lint.Violation) string {
+	if len(violations) == 0 {
+		return ""
 	}
 
-	if len(title) > 0 && unicode.IsUpper(rune(title[0])) {
-		return fmt.Errorf("title should start with lowercase letter (conventional commits)")
+	var b strings.Builder
+	for _, v := range violations {
+		if v.Severity == lint.Error {
+			b.WriteString(errorStyle.Render("❌ "+v.String()) + "\n")
+		} else {
+			b.WriteString(warningStyle.Render("⚠️  "+v.String()) + "\n")
+		}
 	}
-
-	return nil
+	b.WriteString("\n")
+	return b.String()
 }
 
 func splitIntoLines(text string, maxWidth int) []string {
@@ -231,17 +370,26 @@ func splitIntoLines(text string, maxWidth int) []string {
 	return lines
 }
 
-func RunCommitMessage(commitType string) (string, string, bool, error) {
-	p := tea.NewProgram(NewCommitMessageModel(commitType))
-	m, err := p.Run()
+func (m *CommitMessageModel) breakingStatus() string {
+	if m.info.Breaking {
+		return errorStyle.Render("💥 Breaking change (Ctrl+B to unmark)") + "\n"
+	}
+	return promptStyle.Render("Ctrl+B marks this as a breaking change") + "\n"
+}
+
+func (s *Screen) RunCommitMessage(info model.CommitInfo, suggestions TrailerSuggestions, rules config.TitleRules, linter *lint.Engine) (model.CommitInfo, bool, error) {
+	m, err := s.run(NewCommitMessageModel(info, suggestions, rules, linter))
 	if err != nil {
-		return "", "", false, err
+		return info, false, err
 	}
 
-	model := m.(*CommitMessageModel)
-	if !model.confirmed {
-		return "", "", false, nil
+	resultModel := m.(*CommitMessageModel)
+	if resultModel.back {
+		return resultModel.commitInfo(), false, ErrBack
+	}
+	if !resultModel.confirmed {
+		return info, false, nil
 	}
 
-	return model.title, model.description, true, nil
+	return resultModel.commitInfo(), true, nil
 }
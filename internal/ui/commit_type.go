@@ -16,13 +16,32 @@ type CommitTypeModel struct {
 	custom   bool
 	input    string
 	quitting bool
+	back     bool
+
+	suggested string
+	reason    string
+
+	view viewport
 }
 
-func NewCommitTypeModel() *CommitTypeModel {
-	return &CommitTypeModel{
-		types:  model.CommitTypes,
+// NewCommitTypeModel starts the cursor on the suggested type, if any, and
+// shows reason as a hint.
+func NewCommitTypeModel(types []model.CommitType, suggested, reason string) *CommitTypeModel {
+	m := &CommitTypeModel{
+		types:  types,
 		cursor: 0,
 	}
+
+	for i, t := range types {
+		if t.Key == suggested {
+			m.cursor = i
+			m.suggested = suggested
+			m.reason = reason
+			break
+		}
+	}
+
+	return m
 }
 
 func (m *CommitTypeModel) Init() tea.Cmd {
@@ -31,9 +50,22 @@ func (m *CommitTypeModel) Init() tea.Cmd {
 
 func (m *CommitTypeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
+
 	case tea.KeyMsg:
+		if cursor, ok := m.view.pageMove(msg.String(), m.cursor, len(m.types)); ok {
+			m.cursor = cursor
+			return m, nil
+		}
+
 		switch msg.String() {
-		case "ctrl+c", "esc", "q":
+		case "ctrl+c", "q":
+			m.quitting = true
+			return m, tea.Quit
+
+		case "esc", "shift+tab":
+			m.back = true
 			m.quitting = true
 			return m, tea.Quit
 
@@ -68,38 +100,45 @@ func (m *CommitTypeModel) View() string {
 		return ""
 	}
 
-	var b strings.Builder
-
-	b.WriteString(titleStyle.Render("📝 Select Commit Type") + "\n\n")
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("📝 Select Commit Type") + "\n\n")
+	if m.suggested != "" {
+		header.WriteString(promptStyle.Render(truncate(fmt.Sprintf("💡 Suggested %s: %s", m.suggested, m.reason), m.view.width)) + "\n\n")
+	}
+	footer := "\n" + promptStyle.Render("Press 'c' for custom type, Enter to select, Esc to go back, q to quit") + "\n"
 
+	var lines []string
 	for i, t := range m.types {
 		cursor := "  "
 		if m.cursor == i {
 			cursor = "> "
 		}
 
-		line := fmt.Sprintf("%s%d. %-10s - %s\n", cursor, i+1, t.Key, t.Description)
+		line := fmt.Sprintf("%s%d. %-10s - %s", cursor, i+1, t.Key, t.Description)
+		if t.Key == m.suggested {
+			line += " (suggested)"
+		}
+		line = truncate(line, m.view.width)
 		if m.cursor == i {
-			b.WriteString(infoStyle.Render(line))
-		} else {
-			b.WriteString(line)
+			line = infoStyle.Render(line)
 		}
+		lines = append(lines, line)
 	}
+	lines = m.view.render(lines, m.cursor, m.view.avail(header.String(), footer))
 
-	b.WriteString("\n")
-	b.WriteString(promptStyle.Render("Press 'c' for custom type, Enter to select, q to quit\n"))
-
-	return b.String()
+	return header.String() + strings.Join(lines, "\n") + "\n" + footer
 }
 
-func RunCommitTypeSelection() (string, bool, error) {
-	p := tea.NewProgram(NewCommitTypeModel())
-	m, err := p.Run()
+func (s *Screen) RunCommitTypeSelection(types []model.CommitType, suggested, reason string) (string, bool, error) {
+	m, err := s.run(NewCommitTypeModel(types, suggested, reason))
 	if err != nil {
 		return "", false, err
 	}
 
 	resultModel := m.(*CommitTypeModel)
+	if resultModel.back {
+		return "", false, ErrBack
+	}
 	if resultModel.selected == "" && !resultModel.custom {
 		return "", false, nil
 	}
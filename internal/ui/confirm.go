@@ -11,7 +11,10 @@ type ConfirmModel struct {
 	prompt   string
 	options  string // e.g., "(y/n)"
 	result   string
+	back     bool
 	quitting bool
+
+	view viewport
 }
 
 func NewConfirmModel(prompt, options string) *ConfirmModel {
@@ -37,11 +40,19 @@ func (m *ConfirmModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.result = "n"
 			m.quitting = true
 			return m, tea.Quit
-		case "ctrl+c", "esc":
+		case "ctrl+c":
 			m.result = "n"
 			m.quitting = true
 			return m, tea.Quit
 		}
+		if isBack(msg) {
+			m.back = true
+			m.quitting = true
+			return m, tea.Quit
+		}
+
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
 	}
 	return m, nil
 }
@@ -51,18 +62,21 @@ func (m *ConfirmModel) View() string {
 		return ""
 	}
 
+	options := fmt.Sprintf(" %s ", m.options)
 	var b strings.Builder
-	b.WriteString(fmt.Sprintf("%s %s ", m.prompt, m.options))
+	b.WriteString(truncate(m.prompt, budget(m.view.width, options)) + options)
 	return b.String()
 }
 
-func Confirm(prompt, options string) (bool, error) {
-	p := tea.NewProgram(NewConfirmModel(prompt, options))
-	m, err := p.Run()
+func (s *Screen) Confirm(prompt, options string) (bool, error) {
+	m, err := s.run(NewConfirmModel(prompt, options))
 	if err != nil {
 		return false, err
 	}
 
 	model := m.(*ConfirmModel)
+	if model.back {
+		return false, ErrBack
+	}
 	return model.result == "y", nil
 }
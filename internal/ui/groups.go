@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gcm/internal/changes"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// groupRow is a line of the group review: a group header (file == -1) or
+// one of its files.
+type groupRow struct {
+	group int
+	file  int
+}
+
+type GroupModel struct {
+	groups    []changes.Group
+	cursor    int
+	marked    map[int]bool
+	editing   bool // editing the header of the cursor's group
+	input     string
+	err       string
+	quitting  bool
+	confirmed bool
+	manual    bool // the user picks the files instead
+	back      bool
+
+	view viewport
+}
+
+func NewGroupModel(groups []changes.Group) *GroupModel {
+	// Edits must not leak into the caller's slices
+	groups = slices.Clone(groups)
+	for i := range groups {
+		groups[i].Items = slices.Clone(groups[i].Items)
+	}
+
+	return &GroupModel{
+		groups: groups,
+		marked: make(map[int]bool),
+	}
+}
+
+func (m *GroupModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *GroupModel) rows() []groupRow {
+	var rows []groupRow
+	for g, group := range m.groups {
+		rows = append(rows, groupRow{group: g, file: -1})
+		for f := range group.Items {
+			rows = append(rows, groupRow{group: g, file: f})
+		}
+	}
+	return rows
+}
+
+func (m *GroupModel) current() groupRow {
+	rows := m.rows()
+	if m.cursor >= len(rows) {
+		m.cursor = len(rows) - 1
+	}
+	return rows[m.cursor]
+}
+
+// focus moves the cursor to the given group and file.
+func (m *GroupModel) focus(group, file int) {
+	for i, r := range m.rows() {
+		if r.group == group && r.file == file {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *GroupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEdit(msg)
+		}
+
+		if cursor, ok := m.view.pageMove(msg.String(), m.cursor, len(m.rows())); ok {
+			m.cursor = cursor
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			m.quitting = true
+			return m, tea.Quit
+
+		case "f":
+			m.manual = true
+			m.quitting = true
+			return m, tea.Quit
+
+		case "esc", "shift+tab":
+			m.back = true
+			m.quitting = true
+			return m, tea.Quit
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.rows())-1 {
+				m.cursor++
+			}
+
+		case " ":
+			g := m.current().group
+			m.marked[g] = !m.marked[g]
+
+		case "m":
+			m.merge()
+
+		case "<", ">":
+			row := m.current()
+			if row.file < 0 {
+				break
+			}
+			to := row.group - 1
+			if msg.String() == ">" {
+				to = row.group + 1
+			}
+			if to < 0 || to >= len(m.groups) {
+				break
+			}
+			m.moveFile(row, to)
+
+		case "s":
+			// Split the file off into a group of its own
+			row := m.current()
+			if row.file < 0 || len(m.groups[row.group].Items) < 2 {
+				break
+			}
+			m.groups = slices.Insert(m.groups, row.group+1, changes.Group{})
+			m.marked = make(map[int]bool)
+			m.moveFile(row, row.group+1)
+
+		case "e":
+			m.editing = true
+			m.input = m.groups[m.current().group].Header()
+			m.err = ""
+
+		case "enter":
+			m.confirmed = true
+			m.quitting = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+func (m *GroupModel) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+
+	case "esc":
+		m.editing = false
+		m.err = ""
+
+	case "enter":
+		g := m.current().group
+		input := strings.TrimSpace(m.input)
+		if input == "" {
+			m.groups[g].Type, m.groups[g].Scope = "", ""
+		} else {
+			info := model.ParseMessage(input + ":")
+			if info.Type == "" || info.Breaking {
+				m.err = "use type or type(scope), e.g. fix(auth)"
+				return m, nil
+			}
+			if info.Scope != "" {
+				if err := lint.ValidateScope(info.Scope, nil); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+			}
+			m.groups[g].Type, m.groups[g].Scope = info.Type, info.Scope
+		}
+		m.groups[g].Reason = "set in the group review"
+		m.editing = false
+		m.err = ""
+
+	case "backspace":
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+			m.err = ""
+		}
+
+	default:
+		if len(msg.String()) == 1 {
+			m.input += msg.String()
+			m.err = ""
+		}
+	}
+
+	return m, nil
+}
+
+// merge joins the marked groups into the first of them or, with fewer
+// than two marked, the cursor's group with the next one. The suggestion
+// is kept only where the groups agree.
+func (m *GroupModel) merge() {
+	var picked []int
+	for g := range m.groups {
+		if m.marked[g] {
+			picked = append(picked, g)
+		}
+	}
+	if len(picked) < 2 {
+		g := m.current().group
+		if g+1 >= len(m.groups) {
+			return
+		}
+		picked = []int{g, g + 1}
+	}
+
+	into := &m.groups[picked[0]]
+	for _, g := range picked[1:] {
+		other := m.groups[g]
+		into.Items = append(into.Items, other.Items...)
+		if other.Type != into.Type {
+			into.Type, into.Reason = "", ""
+		}
+		if other.Scope != into.Scope {
+			into.Scope = ""
+		}
+	}
+
+	for i := len(picked) - 1; i > 0; i-- {
+		m.groups = slices.Delete(m.groups, picked[i], picked[i]+1)
+	}
+	m.marked = make(map[int]bool)
+	m.focus(picked[0], -1)
+}
+
+// moveFile moves the file of row to the end of group to, dropping the
+// group it leaves if that becomes empty.
+func (m *GroupModel) moveFile(row groupRow, to int) {
+	item := m.groups[row.group].Items[row.file]
+	m.groups[row.group].Items = slices.Delete(m.groups[row.group].Items, row.file, row.file+1)
+	m.groups[to].Items = append(m.groups[to].Items, item)
+	m.focus(to, len(m.groups[to].Items)-1)
+
+	if len(m.groups[row.group].Items) == 0 {
+		m.groups = slices.Delete(m.groups, row.group, row.group+1)
+		m.marked = make(map[int]bool)
+		if to > row.group {
+			to--
+		}
+		m.focus(to, len(m.groups[to].Items)-1)
+	}
+}
+
+func (m *GroupModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	header := titleStyle.Render(fmt.Sprintf("🧩 Proposed Commits (%d)", len(m.groups))) + "\n\n"
+
+	var footer strings.Builder
+	footer.WriteString("\n")
+	if m.editing {
+		footer.WriteString("Type and scope: " + m.input + "_\n\n")
+		if m.err != "" {
+			footer.WriteString(errorStyle.Render("❌ "+m.err) + "\n\n")
+		}
+		footer.WriteString(promptStyle.Render("Enter to save, Esc to cancel") + "\n")
+	} else {
+		footer.WriteString(promptStyle.Render("Each group becomes one commit, in this order") + "\n")
+		footer.WriteString(promptStyle.Render("SPACE mark, m merge marked (or with the next group), </> move file, s split file off, e edit type(scope)") + "\n")
+		footer.WriteString(promptStyle.Render("Enter to accept, f to pick files yourself, Esc to go back, q to quit, PgUp/PgDn, g/G to page") + "\n")
+	}
+
+	var lines []string
+	for i, row := range m.rows() {
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "> "
+		}
+
+		group := m.groups[row.group]
+		var line string
+		if row.file < 0 {
+			mark := "[ ]"
+			if m.marked[row.group] {
+				mark = "[x]"
+			}
+			header := group.Header()
+			if header == "" {
+				header = "(type chosen when committing)"
+			}
+			line = fmt.Sprintf("%s%s %d. %s - %d file(s)", cursor, mark, row.group+1, header, len(group.Items))
+			if group.Reason != "" {
+				line += promptStyle.Render(truncate("  "+group.Reason, budget(m.view.width, line)))
+			}
+		} else {
+			prefix := cursor + "      "
+			line = prefix + truncate(group.Items[row.file].DisplayLabel(), budget(m.view.width, prefix))
+		}
+
+		if m.cursor == i {
+			line = infoStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = m.view.render(lines, m.cursor, m.view.avail(header, footer.String()))
+
+	return header + strings.Join(lines, "\n") + "\n" + footer.String()
+}
+
+// RunGroupReview shows the proposed groups for review and returns them as
+// accepted, or no groups if the user chose to select files manually. ok
+// is false if the user canceled.
+func (s *Screen) RunGroupReview(groups []changes.Group) ([]changes.Group, bool, error) {
+	m, err := s.run(NewGroupModel(groups))
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := m.(*GroupModel)
+	if result.back {
+		return nil, false, ErrBack
+	}
+	if result.manual {
+		return nil, true, nil
+	}
+	if !result.confirmed {
+		return nil, false, nil
+	}
+
+	return result.groups, true, nil
+}
@@ -11,6 +11,9 @@ type InputModel struct {
 	value    string
 	quitting bool
 	canceled bool
+	back     bool
+
+	view viewport
 }
 
 func NewInputModel(prompt string) *InputModel {
@@ -30,10 +33,14 @@ func (m *InputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			m.quitting = true
 			return m, tea.Quit
-		case "ctrl+c", "esc":
+		case "ctrl+c":
 			m.canceled = true
 			m.quitting = true
 			return m, tea.Quit
+		case "esc", "shift+tab":
+			m.back = true
+			m.quitting = true
+			return m, tea.Quit
 		case "backspace":
 			if len(m.value) > 0 {
 				m.value = m.value[:len(m.value)-1]
@@ -43,6 +50,9 @@ func (m *InputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.value += msg.String()
 			}
 		}
+
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
 	}
 	return m, nil
 }
@@ -54,20 +64,23 @@ func (m *InputModel) View() string {
 
 	var b strings.Builder
 	b.WriteString(titleStyle.Render("✏️  Custom Commit Type") + "\n\n")
-	b.WriteString(m.prompt + "\n")
-	b.WriteString("> " + m.value + "_\n\n")
-	b.WriteString(promptStyle.Render("Press Enter to confirm, Esc to cancel\n"))
+	b.WriteString(truncate(m.prompt, m.view.width) + "\n")
+	// Long values scroll so that the end being typed stays visible
+	b.WriteString("> " + truncatePath(m.value, budget(m.view.width, "> _")) + "_\n\n")
+	b.WriteString(promptStyle.Render(truncate("Press Enter to confirm, Esc to go back", m.view.width)) + "\n")
 	return b.String()
 }
 
-func GetInput(prompt string) (string, bool, error) {
-	p := tea.NewProgram(NewInputModel(prompt))
-	m, err := p.Run()
+func (s *Screen) GetInput(prompt string) (string, bool, error) {
+	m, err := s.run(NewInputModel(prompt))
 	if err != nil {
 		return "", false, err
 	}
 
 	model := m.(*InputModel)
+	if model.back {
+		return "", false, ErrBack
+	}
 	if model.canceled {
 		return "", false, nil
 	}
@@ -2,149 +2,507 @@ package ui
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"strings"
 
+	"gcm/internal/diff"
 	"gcm/internal/model"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// DiffFunc returns the unified diff of a change, either the index against
+// HEAD (staged) or the working tree against the index. Untracked paths are
+// rendered as all-additions.
+type DiffFunc func(change model.GitChange, staged bool) (string, error)
+
+// Display order of the change categories
+var displayOrder = []string{"MODIFIED", "ADDED", "DELETED", "RENAMED", "UNTRACKED"}
+
+type rowKind int
+
+const (
+	fileRow rowKind = iota
+	hunkRow
+	lineRow
+	dirRow
+)
+
+type row struct {
+	kind rowKind
+	item int
+	hunk int
+	line int
+
+	// Tree view only
+	dir   string
+	label string
+	depth int
+}
+
+// key identifies the row apart from where the layout puts it.
+func (r row) key() row {
+	r.label, r.depth = "", 0
+	return r
+}
+
 type Model struct {
 	items    []model.GitChange
 	cursor   int
 	selected map[int]bool
 	quitting bool
 	canceled bool
+	back     bool
+
+	// Hunk-level staging
+	loadDiff  DiffFunc
+	diffs     map[int]diff.File
+	partial   map[int]map[lineRef]bool
+	expanded  map[int]bool
+	openHunks map[hunkRef]bool
+	err       string
+
+	// Diff preview pane
+	showPreview   bool
+	previews      map[int][]string
+	previewItem   int
+	previewOffset int
+	previewRows   int
+	view          viewport
+
+	// Path filter, typed after '/' while filtering is set
+	filter    string
+	filtering bool
+
+	// Directory tree layout
+	tree      bool
+	collapsed map[string]bool
+
+	// Files at least this many bytes are marked, unless it is 0
+	largeFile int64
 }
 
-func New(items []model.GitChange) *Model {
-	return &Model{
-		items:    items,
-		cursor:   0,
-		selected: make(map[int]bool),
+func New(items []model.GitChange, loadDiff DiffFunc) *Model {
+	m := &Model{
+		items:     items,
+		cursor:    0,
+		selected:  make(map[int]bool),
+		loadDiff:  loadDiff,
+		diffs:     make(map[int]diff.File),
+		partial:   make(map[int]map[lineRef]bool),
+		expanded:  make(map[int]bool),
+		openHunks: make(map[hunkRef]bool),
+		previews:  make(map[int][]string),
+		collapsed: make(map[string]bool),
+	}
+
+	// Changes already in the index start out selected
+	for i, it := range items {
+		if it.IsStaged() {
+			m.selected[i] = true
+		}
 	}
+
+	return m
 }
 
 func (m *Model) Init() tea.Cmd { return nil }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
 	case tea.KeyMsg:
+		m.err = ""
+		if m.filtering {
+			m.updateFilter(msg)
+			m.followCursor()
+			return m, nil
+		}
+
+		rows := m.rows()
+		if cursor, ok := m.view.pageMove(msg.String(), m.cursor, len(rows)); ok && len(rows) > 0 {
+			m.cursor = cursor
+			m.followCursor()
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.items)-1 {
+			if m.cursor < len(rows)-1 {
 				m.cursor++
 			}
 		case " ", "space":
-			// toggle selection
-			if m.selected[m.cursor] {
-				delete(m.selected, m.cursor)
-			} else {
-				m.selected[m.cursor] = true
+			if len(rows) > 0 {
+				m.toggle(rows[m.cursor])
+			}
+		case "right", "l":
+			if len(rows) > 0 {
+				m.expand(rows[m.cursor])
 			}
+		case "left", "h":
+			if len(rows) > 0 {
+				m.collapse(rows[m.cursor])
+			}
+		case "/":
+			m.filtering = true
+		case "t":
+			m.toggleTree()
+		case "p":
+			m.showPreview = !m.showPreview
+		case "J", "ctrl+d":
+			m.scrollPreview(m.previewHeight() / 2)
+		case "K", "ctrl+u":
+			m.scrollPreview(-m.previewHeight() / 2)
 		case "enter":
 			// Confirm selection
 			m.quitting = true
 			return m, tea.Quit
 		case "a":
-			// Select all
-			for i := range m.items {
-				m.selected[i] = true
-			}
+			// Select all, or all matching the filter
+			m.selectMatching(true)
 		case "d":
-			// Deselect all
-			m.selected = make(map[int]bool)
+			// Deselect all, or all matching the filter
+			m.selectMatching(false)
 		case "i":
-			// Invert selection
-			newSelected := make(map[int]bool)
-			for i := range m.items {
-				if !m.selected[i] {
-					newSelected[i] = true
+			// Invert selection of the files shown
+			for _, i := range m.matching() {
+				switch {
+				case m.partial[i] != nil:
+					m.invertLines(i)
+				case m.selected[i]:
+					delete(m.selected, i)
+				default:
+					m.selected[i] = true
 				}
 			}
-			m.selected = newSelected
-		case "q", "esc", "ctrl+c":
+		case "q", "ctrl+c":
 			m.quitting = true
 			m.canceled = true
 			return m, tea.Quit
+		case "esc", "shift+tab":
+			if m.filter != "" && msg.String() == "esc" {
+				m.setFilter("")
+				break
+			}
+			m.quitting = true
+			m.back = true
+			return m, tea.Quit
 		}
+
+		m.followCursor()
 	}
 	return m, nil
 }
 
+// followCursor scrolls the preview back to the top when the cursor moves
+// to another file.
+func (m *Model) followCursor() {
+	if rows := m.rows(); len(rows) > 0 && rows[m.cursor].item != m.previewItem {
+		m.previewItem = rows[m.cursor].item
+		m.previewOffset = 0
+	}
+}
+
+// order returns the item indices in display order: staged entries first,
+// each section grouped by change type.
+func (m *Model) order() []int {
+	var order []int
+	for _, staged := range []bool{true, false} {
+		categorized := make(map[string][]int)
+		for i, item := range m.items {
+			if item.IsStaged() != staged {
+				continue
+			}
+			typ := item.DisplayType()
+			categorized[typ] = append(categorized[typ], i)
+		}
+
+		for _, typ := range displayOrder {
+			order = append(order, categorized[typ]...)
+		}
+	}
+	return order
+}
+
+func sectionTitle(item model.GitChange) string {
+	if item.IsStaged() {
+		return "STAGED"
+	}
+	return "UNSTAGED"
+}
+
+func (m *Model) rows() []row {
+	if m.tree {
+		return m.treeRows()
+	}
+
+	var rows []row
+	for _, i := range m.matching() {
+		rows = append(rows, m.fileRows(i, 0)...)
+	}
+	return rows
+}
+
+// fileRows returns the row of item i followed by its expanded hunks and
+// lines.
+func (m *Model) fileRows(i, depth int) []row {
+	rows := []row{{kind: fileRow, item: i, depth: depth}}
+	if !m.expanded[i] {
+		return rows
+	}
+	for hi, h := range m.diffs[i].Hunks {
+		rows = append(rows, row{kind: hunkRow, item: i, hunk: hi, depth: depth})
+		if !m.openHunks[hunkRef{i, hi}] {
+			continue
+		}
+		for li, l := range h.Lines {
+			if l.IsChange() {
+				rows = append(rows, row{kind: lineRow, item: i, hunk: hi, line: li, depth: depth})
+			}
+		}
+	}
+	return rows
+}
+
 func (m *Model) View() string {
 	if len(m.items) == 0 {
 		return "No changes detected\n"
 	}
 
-	var b strings.Builder
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("📂 File Selection") + "\n\n")
+	header.WriteString(promptStyle.Render("Navigate with ↑/↓, SPACE to mark, ENTER to continue") + "\n\n")
+	if m.filtering || m.filter != "" {
+		header.WriteString(m.filterView() + "\n\n")
+	}
 
-	b.WriteString(titleStyle.Render("📂 File Selection") + "\n\n")
-	b.WriteString(promptStyle.Render("Navigate with ↑/↓, SPACE to mark, ENTER to continue") + "\n\n")
+	footer := m.footer()
+	rows := m.rows()
+	avail := m.view.avail(header.String(), footer)
 
-	// Group by type for display
-	categorized := make(map[string][]int)
-	for i, item := range m.items {
-		typ := item.DisplayType()
-		categorized[typ] = append(categorized[typ], i)
+	var b strings.Builder
+	b.WriteString(header.String())
+
+	if m.showPreview && len(rows) > 0 {
+		width := m.view.width
+		if width == 0 {
+			width = 120
+		}
+		listWidth := m.listWidth()
+		lines, cursor := m.listLines(rows, listWidth)
+		list := m.view.render(lines, cursor, avail)
+		left := lipgloss.NewStyle().Width(listWidth).Render(strings.Join(list, "\n"))
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, m.renderPreview(rows[m.cursor], width-listWidth-4, avail)))
+		b.WriteString("\n")
+	} else if len(rows) > 0 {
+		lines, cursor := m.listLines(rows, m.listWidth())
+		list := m.view.render(lines, cursor, avail)
+		b.WriteString(strings.Join(list, "\n") + "\n")
+	} else {
+		b.WriteString(warningStyle.Render("No changes match the filter") + "\n")
 	}
 
-	// Display order
-	order := []string{"MODIFIED", "ADDED", "DELETED", "RENAMED", "UNTRACKED"}
+	b.WriteString(footer)
+	return b.String()
+}
 
-	for _, typ := range order {
-		indices, ok := categorized[typ]
-		if !ok || len(indices) == 0 {
-			continue
+// listWidth returns the columns of the file list, which shares the screen
+// with the diff preview when it is open; 0 while the width is unknown.
+func (m *Model) listWidth() int {
+	if m.showPreview {
+		width := m.view.width
+		if width == 0 {
+			width = 120
 		}
+		return width * 2 / 5
+	}
+	return m.view.width
+}
 
-		typeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
-		b.WriteString(typeStyle.Render(fmt.Sprintf("%s:", typ)) + "\n")
+// listLines renders the rows of the list, under section and category
+// headings unless it is a tree, each cut to width columns. It also returns
+// the line of the cursor.
+func (m *Model) listLines(rows []row, width int) ([]string, int) {
+	typeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+	sectionStyle := lipgloss.NewStyle().Bold(true).Underline(true)
+	section, category := "", ""
 
-		for _, i := range indices {
-			it := m.items[i]
-			cursor := " "
-			if m.cursor == i {
-				cursor = ">"
+	var lines []string
+	cursorLine := 0
+	for r, rw := range rows {
+		if rw.kind == fileRow && !m.tree {
+			it := m.items[rw.item]
+			if sec := sectionTitle(it); sec != section {
+				if section != "" {
+					lines = append(lines, "")
+				}
+				section, category = sec, ""
+				lines = append(lines, sectionStyle.Render(sec))
 			}
-
-			checked := " "
-			if m.selected[i] {
-				checked = "x"
+			if typ := it.DisplayType(); typ != category {
+				if category != "" {
+					lines = append(lines, "")
+				}
+				category = typ
+				lines = append(lines, typeStyle.Render(fmt.Sprintf("%s:", typ)))
 			}
+		}
 
-			line := fmt.Sprintf("%s [%s] %s\n", cursor, checked, it.Path)
+		cursor := " "
+		if m.cursor == r {
+			cursor = ">"
+		}
 
-			if m.cursor == i {
-				b.WriteString(infoStyle.Render(line))
-			} else {
-				b.WriteString(line)
-			}
+		prefix := cursor + " " + strings.Repeat("  ", rw.depth)
+		rowWidth := 0
+		if width > 0 {
+			rowWidth = max(width-lipgloss.Width(prefix), 8)
+		}
+		line := prefix + m.renderRow(rw, rowWidth)
+
+		if m.cursor == r {
+			cursorLine = len(lines)
+			lines = append(lines, infoStyle.Render(line))
+		} else {
+			lines = append(lines, line)
 		}
-		b.WriteString("\n")
 	}
+	return lines, cursorLine
+}
+
+func (m *Model) footer() string {
+	var b strings.Builder
+	b.WriteString("\n")
 
-	selectedCount := len(m.selected)
-	totalCount := len(m.items)
-	b.WriteString(promptStyle.Render(fmt.Sprintf("Selected: %d/%d\n", selectedCount, totalCount)))
-	b.WriteString(promptStyle.Render("Shortcuts: 'a' (select all), 'd' (deselect all), 'i' (invert), 'q' (cancel)\n"))
-	b.WriteString(promptStyle.Render("Tip: Group related changes in the same commit\n"))
+	if m.err != "" {
+		b.WriteString(errorStyle.Render("❌ "+m.err) + "\n\n")
+	}
 
+	b.WriteString(m.selectionTotal() + "\n")
+	b.WriteString(promptStyle.Render("Shortcuts: 'a' (select all), 'd' (deselect all), 'i' (invert), '/' (filter), 't' (tree view), Esc (back), 'q' (cancel)") + "\n")
+	if m.tree {
+		b.WriteString(promptStyle.Render("Tree: SPACE on a folder to mark all of it, →/l and ←/h to expand and collapse") + "\n")
+	}
+	b.WriteString(promptStyle.Render("Hunks: →/l to expand a file or hunk, ←/h to collapse, SPACE to pick") + "\n")
+	b.WriteString(promptStyle.Render("Preview: 'p' (toggle diff pane), J/K or Ctrl+D/Ctrl+U (scroll)") + "\n")
+	b.WriteString(promptStyle.Render("Tip: Group related changes in the same commit") + "\n")
 	return b.String()
 }
 
-func Run(items []model.GitChange) ([]model.GitChange, error) {
-	p := tea.NewProgram(New(items))
-	m, err := p.Run()
+// renderRow renders a row cut to width columns, or whole for 0.
+func (m *Model) renderRow(rw row, width int) string {
+	switch rw.kind {
+	case dirRow:
+		return m.renderDir(rw, width)
+	case hunkRow:
+		h := m.diffs[rw.item].Hunks[rw.hunk]
+		return fmt.Sprintf("    [%c] %s", m.hunkState(rw.item, rw.hunk), hunkStyle.Render(truncate(h.Header(), width-8)))
+	case lineRow:
+		l := m.diffs[rw.item].Hunks[rw.hunk].Lines[rw.line]
+		checked := ' '
+		if m.lineSelected(rw.item, lineRef{rw.hunk, rw.line}) {
+			checked = 'x'
+		}
+		text := truncate(string(l.Kind)+l.Text, width-12)
+		if l.Kind == '+' {
+			text = addedStyle.Render(text)
+		} else {
+			text = removedStyle.Render(text)
+		}
+		return fmt.Sprintf("        [%c] %s", checked, text)
+	}
+
+	it := m.items[rw.item]
+	stat := m.fileStat(it)
+	if stat != "" {
+		stat = " " + stat
+	}
+
+	marker := " "
+	if it.CanStagePartially() {
+		marker = "▸"
+		if m.expanded[rw.item] {
+			marker = "▾"
+		}
+	}
+
+	if m.tree {
+		prefix := fmt.Sprintf("[%c] %s %s ", m.fileState(rw.item), marker, statusTag(it.DisplayType()))
+		var notes string
+		if it.OrigPath != "" {
+			notes += " (from " + it.OrigPath + ")"
+		}
+		if it.IsStaged() {
+			notes += " (staged)"
+		}
+
+		// The folder rows above already show the directory
+		from := 0
+		if dir := path.Dir(strings.TrimSuffix(it.Path, "/")); dir != "." {
+			from = len(dir) + 1
+		}
+		name := m.pathLabel(it.Path, from, budget(width, prefix+stat))
+		return prefix + name + promptStyle.Render(truncate(notes, budget(width, prefix+it.Path[from:]+stat))) + stat
+	}
+
+	prefix := fmt.Sprintf("[%c] %s ", m.fileState(rw.item), marker)
+	if it.OrigPath != "" {
+		orig := truncatePath(it.OrigPath, budget(width, prefix+stat)/3) + " -> "
+		return prefix + orig + m.pathLabel(it.Path, 0, budget(width, prefix+orig+stat)) + stat
+	}
+	return prefix + m.pathLabel(it.Path, 0, budget(width, prefix+stat)) + stat
+}
+
+// budget returns the columns left of width after used, at least one, or 0
+// for an unlimited width.
+func budget(width int, used string) int {
+	if width <= 0 {
+		return 0
+	}
+	return max(width-lipgloss.Width(used), 1)
+}
+
+// Run lets the user pick changes. selected is the pick of an earlier visit
+// to the same changes, or nil to start from the index. Files of largeFile
+// bytes or more are marked as large.
+func (s *Screen) Run(items, selected []model.GitChange, loadDiff DiffFunc, largeFile int64) ([]model.GitChange, error) {
+	var menu *Model
+	switch {
+	case selected != nil && s != nil && s.menu != nil && slices.Equal(s.menu.items, items):
+		menu = s.menu
+		menu.quitting, menu.canceled, menu.back, menu.filtering = false, false, false, false
+	default:
+		menu = New(items, loadDiff)
+		if selected != nil {
+			menu.selected = make(map[int]bool)
+			for i, it := range items {
+				menu.selected[i] = slices.ContainsFunc(selected, func(s model.GitChange) bool {
+					s.Patch = ""
+					return s == it
+				})
+			}
+		}
+	}
+	menu.largeFile = largeFile
+	if s != nil {
+		s.menu = menu
+	}
+
+	m, err := s.run(menu)
 	if err != nil {
 		return nil, err
 	}
 
 	modelPtr := m.(*Model)
+	if modelPtr.back {
+		return nil, ErrBack
+	}
 	if modelPtr.canceled {
 		return nil, nil
 	}
@@ -153,6 +511,12 @@ func Run(items []model.GitChange) ([]model.GitChange, error) {
 	for i := range modelPtr.items {
 		if modelPtr.selected[i] {
 			res = append(res, modelPtr.items[i])
+		} else if lines := modelPtr.partial[i]; lines != nil {
+			it := modelPtr.items[i]
+			it.Patch = modelPtr.diffs[i].Patch(func(hunk, line int) bool {
+				return lines[lineRef{hunk, line}]
+			})
+			res = append(res, it)
 		}
 	}
 	return res, nil
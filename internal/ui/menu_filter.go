@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gcm/internal/changes"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var matchStyle = lipgloss.NewStyle().
+	Bold(true).
+	Underline(true).
+	Foreground(lipgloss.Color("212"))
+
+// isGlob reports whether a filter is a glob pattern rather than a fuzzy
+// query.
+func isGlob(filter string) bool {
+	return strings.ContainsAny(filter, "*?[")
+}
+
+// fuzzyMatch reports whether the runes of query appear in s in order,
+// ignoring case, and returns the byte offsets of the matched runes in s.
+func fuzzyMatch(query, s string) ([]int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return nil, true
+	}
+
+	var pos []int
+	for i, r := range s {
+		if unicode.ToLower(r) == q[len(pos)] {
+			pos = append(pos, i)
+			if len(pos) == len(q) {
+				return pos, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// matches reports whether item i passes the filter: a glob for patterns
+// with *, ? or [, like internal/**/*_test.go, and a fuzzy match on the
+// path otherwise. Renames match by either path.
+func (m *Model) matches(i int) bool {
+	if m.filter == "" {
+		return true
+	}
+
+	it := m.items[i]
+	paths := []string{it.Path}
+	if it.OrigPath != "" {
+		paths = append(paths, it.OrigPath)
+	}
+	for _, p := range paths {
+		if isGlob(m.filter) {
+			if changes.MatchGlob(m.filter, p) {
+				return true
+			}
+		} else if _, ok := fuzzyMatch(m.filter, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matching returns the items passing the filter, in display order.
+func (m *Model) matching() []int {
+	var res []int
+	for _, i := range m.order() {
+		if m.matches(i) {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// hiddenSelected counts the picked items the filter hides; they stay
+// picked and are committed all the same.
+func (m *Model) hiddenSelected() int {
+	count := 0
+	for i := range m.items {
+		if !m.matches(i) && m.fileState(i) != ' ' {
+			count++
+		}
+	}
+	return count
+}
+
+// highlightPath renders p from byte offset from on, marking the runes the
+// fuzzy filter matched.
+func (m *Model) highlightPath(p string, from int) string {
+	var pos []int
+	if m.filter != "" && !isGlob(m.filter) {
+		pos, _ = fuzzyMatch(m.filter, p)
+	}
+
+	var b strings.Builder
+	next := 0
+	for i, r := range p {
+		for next < len(pos) && pos[next] < i {
+			next++
+		}
+		switch {
+		case i < from:
+		case next < len(pos) && pos[next] == i:
+			b.WriteString(matchStyle.Render(string(r)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pathLabel renders p from byte offset from on like highlightPath, cut at
+// the start with an ellipsis to fit width columns.
+func (m *Model) pathLabel(p string, from, width int) string {
+	cut := from + truncateStart(p[from:], width)
+	label := m.highlightPath(p, cut)
+	if cut > from {
+		label = "…" + label
+	}
+	return label
+}
+
+// filterView shows the filter, the number of matches and the picked
+// changes it hides.
+func (m *Model) filterView() string {
+	query := m.filter
+	if m.filtering {
+		query += "_"
+	}
+
+	status := fmt.Sprintf("%d/%d match", len(m.matching()), len(m.items))
+	if hidden := m.hiddenSelected(); hidden > 0 {
+		status += fmt.Sprintf(", %d selected hidden", hidden)
+	}
+
+	help := "'a'/'d' (select/deselect matching), Esc (clear)"
+	if m.filtering {
+		help = "ENTER (back to the list), Ctrl+A (select matching), Esc (clear)"
+	}
+	return infoStyle.Render("🔍 /"+query) + " " + promptStyle.Render("("+status+")") + "\n" + promptStyle.Render(help)
+}
+
+// updateFilter edits the filter while it is being typed. Enter keeps it
+// and returns to the list, Esc clears it, and Ctrl+A picks every match.
+func (m *Model) updateFilter(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "enter":
+		m.filtering = false
+	case "esc":
+		m.filtering = false
+		m.setFilter("")
+	case "ctrl+a":
+		m.filtering = false
+		m.selectMatching(true)
+	case "backspace":
+		if r := []rune(m.filter); len(r) > 0 {
+			m.setFilter(string(r[:len(r)-1]))
+		}
+	case "ctrl+u":
+		m.setFilter("")
+	default:
+		if msg.Type == tea.KeyRunes && !msg.Alt {
+			m.setFilter(m.filter + string(msg.Runes))
+		} else if msg.Type == tea.KeySpace {
+			m.setFilter(m.filter + " ")
+		}
+	}
+}
+
+func (m *Model) setFilter(filter string) {
+	m.filter = filter
+	m.cursor = 0
+}
+
+// selectMatching picks or drops every item the filter shows.
+func (m *Model) selectMatching(on bool) {
+	for _, i := range m.matching() {
+		if on {
+			m.selected[i] = true
+		} else {
+			delete(m.selected, i)
+		}
+		delete(m.partial, i)
+	}
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+
+	"gcm/internal/diff"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	hunkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39"))
+
+	addedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42"))
+
+	removedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("203"))
+)
+
+type hunkRef struct {
+	item int
+	hunk int
+}
+
+type lineRef struct {
+	hunk int
+	line int
+}
+
+// changeLines lists every added or removed line of a file's diff.
+func (m *Model) changeLines(i int) []lineRef {
+	var refs []lineRef
+	for hi, h := range m.diffs[i].Hunks {
+		for li, l := range h.Lines {
+			if l.IsChange() {
+				refs = append(refs, lineRef{hi, li})
+			}
+		}
+	}
+	return refs
+}
+
+func (m *Model) hunkLines(i, hunk int) []lineRef {
+	var refs []lineRef
+	for li, l := range m.diffs[i].Hunks[hunk].Lines {
+		if l.IsChange() {
+			refs = append(refs, lineRef{hunk, li})
+		}
+	}
+	return refs
+}
+
+func (m *Model) lineSelected(i int, ref lineRef) bool {
+	return m.selected[i] || m.partial[i][ref]
+}
+
+// setLines marks the given lines of item i and folds the result back into
+// a whole-file selection when every line (or none) ends up selected.
+func (m *Model) setLines(i int, refs []lineRef, on bool) {
+	lines := m.partial[i]
+	if lines == nil {
+		lines = make(map[lineRef]bool)
+		if m.selected[i] {
+			for _, ref := range m.changeLines(i) {
+				lines[ref] = true
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if on {
+			lines[ref] = true
+		} else {
+			delete(lines, ref)
+		}
+	}
+
+	delete(m.selected, i)
+	delete(m.partial, i)
+	switch len(lines) {
+	case 0:
+	case len(m.changeLines(i)):
+		m.selected[i] = true
+	default:
+		m.partial[i] = lines
+	}
+}
+
+func (m *Model) invertLines(i int) {
+	lines := make(map[lineRef]bool)
+	for _, ref := range m.changeLines(i) {
+		if !m.partial[i][ref] {
+			lines[ref] = true
+		}
+	}
+	m.partial[i] = lines
+}
+
+func (m *Model) fileState(i int) rune {
+	switch {
+	case m.selected[i]:
+		return 'x'
+	case m.partial[i] != nil:
+		return '~'
+	default:
+		return ' '
+	}
+}
+
+func (m *Model) hunkState(i, hunk int) rune {
+	refs := m.hunkLines(i, hunk)
+	count := 0
+	for _, ref := range refs {
+		if m.lineSelected(i, ref) {
+			count++
+		}
+	}
+	switch count {
+	case 0:
+		return ' '
+	case len(refs):
+		return 'x'
+	default:
+		return '~'
+	}
+}
+
+func (m *Model) toggle(rw row) {
+	switch rw.kind {
+	case dirRow:
+		m.toggleDir(rw.dir)
+	case fileRow:
+		if m.fileState(rw.item) == 'x' {
+			delete(m.selected, rw.item)
+		} else {
+			m.selected[rw.item] = true
+		}
+		delete(m.partial, rw.item)
+	case hunkRow:
+		m.setLines(rw.item, m.hunkLines(rw.item, rw.hunk), m.hunkState(rw.item, rw.hunk) != 'x')
+	case lineRow:
+		ref := lineRef{rw.hunk, rw.line}
+		m.setLines(rw.item, []lineRef{ref}, !m.lineSelected(rw.item, ref))
+	}
+}
+
+func (m *Model) expand(rw row) {
+	switch rw.kind {
+	case dirRow:
+		delete(m.collapsed, rw.dir)
+	case fileRow:
+		it := m.items[rw.item]
+		if !it.CanStagePartially() {
+			m.err = "hunk selection is only available for modified tracked files"
+			return
+		}
+		if _, ok := m.diffs[rw.item]; !ok {
+			if m.loadDiff == nil {
+				return
+			}
+			out, err := m.loadDiff(it, false)
+			if err != nil {
+				m.err = fmt.Sprintf("could not load diff: %v", err)
+				return
+			}
+			file := diff.Parse(out)
+			if len(file.Hunks) == 0 {
+				m.err = "no text hunks to select (binary or mode-only change)"
+				return
+			}
+			m.diffs[rw.item] = file
+		}
+		m.expanded[rw.item] = true
+	case hunkRow:
+		m.openHunks[hunkRef{rw.item, rw.hunk}] = true
+	}
+}
+
+func (m *Model) collapse(rw row) {
+	target := row{kind: fileRow, item: rw.item}
+
+	switch rw.kind {
+	case dirRow:
+		if m.collapsed[rw.dir] {
+			m.parentRow(rw)
+			return
+		}
+		m.collapsed[rw.dir] = true
+		target = rw
+	case fileRow:
+		if m.tree && !m.expanded[rw.item] {
+			m.parentRow(rw)
+			return
+		}
+		m.expanded[rw.item] = false
+	case hunkRow:
+		ref := hunkRef{rw.item, rw.hunk}
+		if m.openHunks[ref] {
+			delete(m.openHunks, ref)
+			target = rw
+		} else {
+			m.expanded[rw.item] = false
+		}
+	case lineRow:
+		delete(m.openHunks, hunkRef{rw.item, rw.hunk})
+		target = row{kind: hunkRow, item: rw.item, hunk: rw.hunk}
+	}
+
+	for r, other := range m.rows() {
+		if other.key() == target.key() {
+			m.cursor = r
+			return
+		}
+	}
+}
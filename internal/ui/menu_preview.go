@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffHeaderStyle = lipgloss.NewStyle().
+			Bold(true)
+
+	keywordStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("176"))
+
+	stringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("221"))
+
+	commentStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")).
+			Italic(true)
+
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1)
+)
+
+type syntax struct {
+	comment  string
+	keywords map[string]bool
+}
+
+func newSyntax(comment string, keywords string) *syntax {
+	s := &syntax{comment: comment, keywords: make(map[string]bool)}
+	for _, kw := range strings.Fields(keywords) {
+		s.keywords[kw] = true
+	}
+	return s
+}
+
+var (
+	goSyntax  = newSyntax("//", "break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false")
+	jsSyntax  = newSyntax("//", "async await break case catch class const continue default delete do else export extends finally for from function if import in instanceof let new return switch this throw try typeof var void while yield null undefined true false interface type enum")
+	rsSyntax  = newSyntax("//", "as async await break const continue crate else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while true false")
+	pySyntax  = newSyntax("#", "and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False")
+	shSyntax  = newSyntax("#", "if then else elif fi case esac for while until do done in function return local export")
+	cfgSyntax = newSyntax("#", "true false null")
+)
+
+func syntaxFor(path string) *syntax {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return goSyntax
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".java", ".c", ".h", ".cpp", ".cc", ".cs", ".kt", ".swift":
+		return jsSyntax
+	case ".rs":
+		return rsSyntax
+	case ".py":
+		return pySyntax
+	case ".sh", ".bash", ".zsh":
+		return shSyntax
+	case ".yaml", ".yml", ".toml":
+		return cfgSyntax
+	}
+	return nil
+}
+
+// highlight colors comments, string literals and keywords of a source line,
+// rendering everything else with base.
+func highlight(text string, syn *syntax, base lipgloss.Style) string {
+	if syn == nil {
+		return base.Render(text)
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	plain := 0
+
+	flush := func(end int) {
+		if end > plain {
+			b.WriteString(base.Render(string(runes[plain:end])))
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case strings.HasPrefix(string(runes[i:]), syn.comment):
+			flush(i)
+			b.WriteString(commentStyle.Render(string(runes[i:])))
+			return b.String()
+
+		case r == '"' || r == '\'' || r == '`':
+			flush(i)
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			b.WriteString(stringStyle.Render(string(runes[i : j+1])))
+			i = j + 1
+			plain = i
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			if syn.keywords[string(runes[i:j])] {
+				flush(i)
+				b.WriteString(keywordStyle.Render(string(runes[i:j])))
+				plain = j
+			}
+			i = j
+
+		default:
+			i++
+		}
+	}
+	flush(len(runes))
+
+	return b.String()
+}
+
+func renderDiff(out string, syn *syntax) []string {
+	var lines []string
+	inHeader := true
+
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch {
+		case strings.HasPrefix(line, "diff --git"):
+			inHeader = true
+			lines = append(lines, diffHeaderStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			lines = append(lines, hunkStyle.Render(line))
+		case inHeader:
+			lines = append(lines, diffHeaderStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, addedStyle.Render("+")+highlight(line[1:], syn, addedStyle))
+		case strings.HasPrefix(line, "-"):
+			lines = append(lines, removedStyle.Render("-")+highlight(line[1:], syn, removedStyle))
+		case strings.HasPrefix(line, `\`):
+			lines = append(lines, promptStyle.Render(line))
+		case line == "":
+			lines = append(lines, "")
+		default:
+			lines = append(lines, line[:1]+highlight(line[1:], syn, lipgloss.NewStyle()))
+		}
+	}
+
+	return lines
+}
+
+// preview returns the rendered diff of item i, loading it on first use.
+func (m *Model) preview(i int) []string {
+	if lines, ok := m.previews[i]; ok {
+		return lines
+	}
+
+	it := m.items[i]
+	syn := syntaxFor(it.Path)
+
+	type section struct {
+		title  string
+		staged bool
+	}
+	var sections []section
+	switch {
+	case it.Index == '?':
+		sections = append(sections, section{"Untracked", false})
+	default:
+		if it.Index != ' ' {
+			sections = append(sections, section{"Staged", true})
+		}
+		if it.Working != ' ' {
+			sections = append(sections, section{"Unstaged", false})
+		}
+	}
+
+	var lines []string
+	for _, sec := range sections {
+		lines = append(lines, titleStyle.Render(fmt.Sprintf("── %s ──", sec.title)))
+		if m.loadDiff == nil {
+			continue
+		}
+		out, err := m.loadDiff(it, sec.staged)
+		switch {
+		case err != nil:
+			lines = append(lines, errorStyle.Render(fmt.Sprintf("could not load diff: %v", err)))
+		case strings.TrimSpace(out) == "":
+			lines = append(lines, promptStyle.Render("(no textual changes)"))
+		default:
+			lines = append(lines, renderDiff(out, syn)...)
+		}
+		lines = append(lines, "")
+	}
+
+	m.previews[i] = lines
+	return lines
+}
+
+// previewHeight returns the diff lines the preview showed last, or a
+// guess before it is drawn.
+func (m *Model) previewHeight() int {
+	if m.previewRows > 0 {
+		return m.previewRows
+	}
+	height := m.view.height
+	if height == 0 {
+		height = 40
+	}
+	return max(height-12, 8)
+}
+
+func (m *Model) scrollPreview(delta int) {
+	m.previewOffset = max(m.previewOffset+delta, 0)
+}
+
+// renderPreview draws the diff pane width columns wide and, unless avail
+// is negative, as many lines high as the list next to it.
+func (m *Model) renderPreview(rw row, width, avail int) string {
+	var lines []string
+	title := rw.dir + "/"
+	if rw.kind == dirRow {
+		lines = m.dirPreview(rw)
+	} else {
+		lines = m.preview(rw.item)
+		title = m.items[rw.item].Path
+	}
+	height := m.previewHeight()
+	if avail >= 0 {
+		// Less the border, the blank line and the footer
+		height = max(avail-4, 3)
+		m.previewRows = height
+	}
+
+	m.previewOffset = min(m.previewOffset, max(len(lines)-height, 0))
+	end := min(m.previewOffset+height, len(lines))
+	visible := lines[m.previewOffset:end]
+
+	footer := promptStyle.Render(truncate(fmt.Sprintf("%s  lines %d-%d of %d", truncatePath(title, width/2), m.previewOffset+1, end, len(lines)), width))
+	body := lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(visible, "\n"))
+
+	return previewStyle.Width(width + 2).Render(body + "\n\n" + footer)
+}
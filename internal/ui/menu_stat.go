@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"gcm/internal/model"
+)
+
+// fileStat renders the size of a change next to its path: the lines it
+// adds and removes, or "binary", and a warning with the file size when
+// the file is at least largeFile bytes.
+func (m *Model) fileStat(it model.GitChange) string {
+	var parts []string
+	switch {
+	case it.Stat.Binary:
+		parts = append(parts, promptStyle.Render("binary"))
+	case it.Stat.Added > 0 || it.Stat.Removed > 0:
+		parts = append(parts, lineCounts(it.Stat.Added, it.Stat.Removed))
+	}
+	if m.isLarge(it) {
+		parts = append(parts, warningStyle.Render("⚠ "+formatSize(it.Stat.Size)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m *Model) isLarge(it model.GitChange) bool {
+	return m.largeFile > 0 && it.Stat.Size >= m.largeFile
+}
+
+// formatSize renders a byte count in binary units, e.g. "2.5 MiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for rest := n / unit; rest >= unit; rest /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// selectedLines counts the lines item i commits: all of them when the
+// whole file is picked, the picked ones otherwise.
+func (m *Model) selectedLines(i int) (added, removed int) {
+	if m.selected[i] {
+		return m.items[i].Stat.Added, m.items[i].Stat.Removed
+	}
+
+	for ref := range m.partial[i] {
+		switch m.diffs[i].Hunks[ref.hunk].Lines[ref.line].Kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// selectionTotal sums up the picked changes for the footer: files, lines,
+// and how many are binary or large.
+func (m *Model) selectionTotal() string {
+	files, added, removed, binary, large := 0, 0, 0, 0, 0
+	for i, it := range m.items {
+		if m.fileState(i) == ' ' {
+			continue
+		}
+		files++
+		a, r := m.selectedLines(i)
+		added += a
+		removed += r
+		if it.Stat.Binary {
+			binary++
+		}
+		if m.isLarge(it) {
+			large++
+		}
+	}
+
+	total := promptStyle.Render(fmt.Sprintf("Selected: %d/%d files,", files, len(m.items))) + " " +
+		lineCounts(added, removed) + promptStyle.Render(" lines")
+	if binary > 0 {
+		total += promptStyle.Render(fmt.Sprintf(", %d binary", binary))
+	}
+	if large > 0 {
+		total += warningStyle.Render(fmt.Sprintf(", %d over %s", large, formatSize(m.largeFile)))
+	}
+	return total
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var dirStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("75"))
+
+// treeNode is a directory of the tree view. Directories holding nothing
+// but one other directory are compacted into it, so name can span several
+// levels.
+type treeNode struct {
+	name  string
+	path  string
+	dirs  []*treeNode
+	files []int
+}
+
+// buildTree nests the given items by directory, folders first and both
+// sorted by name.
+func (m *Model) buildTree(items []int) *treeNode {
+	root := &treeNode{path: "."}
+	nodes := map[string]*treeNode{".": root}
+
+	var dirOf func(string) *treeNode
+	dirOf = func(d string) *treeNode {
+		if n, ok := nodes[d]; ok {
+			return n
+		}
+		parent := dirOf(path.Dir(d))
+		n := &treeNode{name: path.Base(d), path: d}
+		parent.dirs = append(parent.dirs, n)
+		nodes[d] = n
+		return n
+	}
+
+	for _, i := range items {
+		n := dirOf(path.Dir(strings.TrimSuffix(m.items[i].Path, "/")))
+		n.files = append(n.files, i)
+	}
+
+	m.sortTree(root)
+	return root
+}
+
+func (m *Model) sortTree(n *treeNode) {
+	for _, d := range n.dirs {
+		for len(d.files) == 0 && len(d.dirs) == 1 {
+			child := d.dirs[0]
+			d.name += "/" + child.name
+			d.path, d.dirs, d.files = child.path, child.dirs, child.files
+		}
+		m.sortTree(d)
+	}
+
+	sort.Slice(n.dirs, func(a, b int) bool { return n.dirs[a].name < n.dirs[b].name })
+	// Stable keeps a staged entry before the unstaged one of the same path
+	sort.SliceStable(n.files, func(a, b int) bool { return m.items[n.files[a]].Path < m.items[n.files[b]].Path })
+}
+
+// treeRows lists the tree view: each folder, then its contents unless it
+// is collapsed.
+func (m *Model) treeRows() []row {
+	var rows []row
+	var walk func(n *treeNode, depth int)
+	walk = func(n *treeNode, depth int) {
+		for _, d := range n.dirs {
+			rows = append(rows, row{kind: dirRow, item: -1, dir: d.path, label: d.name, depth: depth})
+			if !m.collapsed[d.path] {
+				walk(d, depth+1)
+			}
+		}
+		for _, i := range n.files {
+			rows = append(rows, m.fileRows(i, depth)...)
+		}
+	}
+	walk(m.buildTree(m.matching()), 0)
+	return rows
+}
+
+// subtree returns the items shown below folder dir.
+func (m *Model) subtree(dir string) []int {
+	var res []int
+	for _, i := range m.matching() {
+		if strings.HasPrefix(m.items[i].Path, dir+"/") {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+func (m *Model) dirState(dir string) rune {
+	all, some := true, false
+	for _, i := range m.subtree(dir) {
+		state := m.fileState(i)
+		all = all && state == 'x'
+		some = some || state != ' '
+	}
+	switch {
+	case all:
+		return 'x'
+	case some:
+		return '~'
+	default:
+		return ' '
+	}
+}
+
+// toggleDir picks every change below dir, or drops them all if they are
+// all picked already.
+func (m *Model) toggleDir(dir string) {
+	on := m.dirState(dir) != 'x'
+	for _, i := range m.subtree(dir) {
+		if on {
+			m.selected[i] = true
+		} else {
+			delete(m.selected, i)
+		}
+		delete(m.partial, i)
+	}
+}
+
+func (m *Model) renderDir(rw row, width int) string {
+	items := m.subtree(rw.dir)
+
+	picked, added, removed := 0, 0, 0
+	for _, i := range items {
+		if m.fileState(i) != ' ' {
+			picked++
+		}
+		added += m.items[i].Stat.Added
+		removed += m.items[i].Stat.Removed
+	}
+
+	marker := "▾"
+	if m.collapsed[rw.dir] {
+		marker = "▸"
+	}
+	prefix := fmt.Sprintf("[%c] %s ", m.dirState(rw.dir), marker)
+	counts := " " + promptStyle.Render(fmt.Sprintf("%d/%d selected", picked, len(items))) + " " + lineCounts(added, removed)
+	label := truncatePath(rw.label+"/", budget(width, prefix+counts))
+	return prefix + dirStyle.Render(label) + counts
+}
+
+// lineCounts renders added and removed line counts as "+a -r".
+func lineCounts(added, removed int) string {
+	return addedStyle.Render(fmt.Sprintf("+%d", added)) + " " + removedStyle.Render(fmt.Sprintf("-%d", removed))
+}
+
+// dirPreview lists the changes below a folder in the preview pane.
+func (m *Model) dirPreview(rw row) []string {
+	lines := []string{titleStyle.Render(fmt.Sprintf("── %s/ ──", rw.dir))}
+	for _, i := range m.subtree(rw.dir) {
+		it := m.items[i]
+		lines = append(lines, fmt.Sprintf("[%c] %s %s %s", m.fileState(i), statusTag(it.DisplayType()),
+			strings.TrimPrefix(it.Path, rw.dir+"/"), m.fileStat(it)))
+	}
+	return lines
+}
+
+// statusTag abbreviates a DisplayType for the tree view, which has no
+// category headings.
+func statusTag(typ string) string {
+	if typ == "UNTRACKED" {
+		return "?"
+	}
+	return typ[:1]
+}
+
+// parentRow moves the cursor to the folder holding the row under it.
+func (m *Model) parentRow(rw row) {
+	rows := m.rows()
+	for r := m.cursor - 1; r >= 0; r-- {
+		if rows[r].kind == dirRow && rows[r].depth < rw.depth {
+			m.cursor = r
+			return
+		}
+	}
+}
+
+// toggleTree switches between the category list and the tree, keeping the
+// cursor on the same change.
+func (m *Model) toggleTree() {
+	item := -1
+	if rows := m.rows(); len(rows) > 0 {
+		item = rows[m.cursor].item
+	}
+
+	m.tree = !m.tree
+	m.cursor = 0
+	for r, rw := range m.rows() {
+		if rw.kind == fileRow && rw.item == item {
+			m.cursor = r
+			return
+		}
+	}
+}
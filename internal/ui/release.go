@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ReleaseModel shows the next version and the commits it includes, and
+// asks whether to tag it.
+type ReleaseModel struct {
+	current   string
+	next      string
+	reason    string
+	commits   []string
+	confirmed bool
+	quitting  bool
+
+	view viewport
+}
+
+func NewReleaseModel(current, next, reason string, commits []string) *ReleaseModel {
+	return &ReleaseModel{
+		current: current,
+		next:    next,
+		reason:  reason,
+		commits: commits,
+	}
+}
+
+func (m *ReleaseModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *ReleaseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
+
+	case tea.KeyMsg:
+		// The list has no cursor; paging moves its top line
+		if offset, ok := m.view.pageMove(msg.String(), m.view.offset, len(m.commits)); ok {
+			m.view.offset = offset
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "y", "enter":
+			m.confirmed = true
+			m.quitting = true
+			return m, tea.Quit
+
+		case "n", "q", "esc", "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+
+		case "down", "j":
+			m.view.offset++
+
+		case "up", "k":
+			m.view.offset = max(m.view.offset-1, 0)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *ReleaseModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("🏷️  Release") + "\n\n")
+	current := m.current
+	if current == "" {
+		current = "(no release yet)"
+	}
+	header.WriteString(fmt.Sprintf("%s → %s\n", current, infoStyle.Render(m.next)))
+	header.WriteString(promptStyle.Render(m.reason) + "\n\n")
+	header.WriteString(fmt.Sprintf("%d commit(s) included:\n", len(m.commits)))
+
+	footer := fmt.Sprintf("\nCreate tag %s? (y/n) ", m.next)
+
+	var lines []string
+	for _, c := range m.commits {
+		lines = append(lines, "  "+truncate(c, budget(m.view.width, "  ")))
+	}
+	lines = m.view.render(lines, -1, m.view.avail(header.String(), footer))
+
+	return header.String() + strings.Join(lines, "\n") + "\n" + footer
+}
+
+func (s *Screen) RunReleaseConfirm(current, next, reason string, commits []string) (bool, error) {
+	m, err := s.run(NewReleaseModel(current, next, reason, commits))
+	if err != nil {
+		return false, err
+	}
+
+	return m.(*ReleaseModel).confirmed, nil
+}
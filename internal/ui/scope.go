@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gcm/internal/lint"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type ScopeModel struct {
+	commitType  string
+	catalogue   []string
+	suggestions []string
+	input       string
+	cursor      int // -1 while editing the input, otherwise a filtered suggestion
+	err         string
+	quitting    bool
+	confirmed   bool
+	back        bool
+	scope       string
+
+	view viewport
+}
+
+// NewScopeModel suggests the scopes derived from the changes. With a
+// non-empty catalogue only its entries are valid, so the suggestions are
+// the catalogue, ordered by what the changes touch. current pre-fills the
+// input.
+func NewScopeModel(commitType, current string, suggestions, catalogue []string) *ScopeModel {
+	all := suggestions
+	if len(catalogue) > 0 {
+		// Configured scopes touched by the changes go first
+		all = nil
+		for _, s := range suggestions {
+			if slices.Contains(catalogue, s) {
+				all = append(all, s)
+			}
+		}
+		for _, s := range catalogue {
+			if !slices.Contains(all, s) {
+				all = append(all, s)
+			}
+		}
+	}
+
+	return &ScopeModel{
+		commitType:  commitType,
+		catalogue:   catalogue,
+		suggestions: all,
+		input:       current,
+		cursor:      -1,
+	}
+}
+
+func (m *ScopeModel) Init() tea.Cmd {
+	return nil
+}
+
+// filtered returns the suggestions starting with the current input.
+func (m *ScopeModel) filtered() []string {
+	var res []string
+	for _, s := range m.suggestions {
+		if strings.HasPrefix(s, m.input) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (m *ScopeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.resize(msg)
+
+	case tea.KeyMsg:
+		filtered := m.filtered()
+
+		switch msg.String() {
+		case "pgup", "pgdown":
+			// g/G would be typed into the input
+			if len(filtered) > 0 {
+				m.cursor, _ = m.view.pageMove(msg.String(), max(m.cursor, 0), len(filtered))
+			}
+
+		case "ctrl+c":
+			m.quitting = true
+			return m, tea.Quit
+
+		case "esc", "shift+tab":
+			m.back = true
+			m.quitting = true
+			return m, tea.Quit
+
+		case "up":
+			if m.cursor >= 0 {
+				m.cursor--
+			}
+
+		case "down":
+			if m.cursor < len(filtered)-1 {
+				m.cursor++
+			}
+
+		case "tab":
+			if m.cursor >= 0 {
+				m.input = filtered[m.cursor]
+			} else if len(filtered) > 0 {
+				m.input = filtered[0]
+			}
+			m.cursor = -1
+			m.err = ""
+
+		case "enter":
+			scope := strings.TrimSpace(m.input)
+			if m.cursor >= 0 {
+				scope = filtered[m.cursor]
+			}
+			if scope != "" {
+				if err := lint.ValidateScope(scope, m.catalogue); err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+			}
+			m.scope = scope
+			m.confirmed = true
+			m.quitting = true
+			return m, tea.Quit
+
+		case "backspace":
+			if len(m.input) > 0 {
+				m.input = m.input[:len(m.input)-1]
+				m.cursor = -1
+				m.err = ""
+			}
+
+		default:
+			if len(msg.String()) == 1 {
+				m.input += msg.String()
+				m.cursor = -1
+				m.err = ""
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *ScopeModel) View() string {
+	if m.quitting && m.confirmed {
+		return ""
+	}
+
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("🏷️  Commit Scope (Optional)") + "\n\n")
+	header.WriteString(fmt.Sprintf("Type: %s\n\n", infoStyle.Render(m.commitType)))
+	header.WriteString("Scope: " + m.input + "_\n\n")
+	if m.err != "" {
+		header.WriteString(errorStyle.Render("❌ "+m.err) + "\n\n")
+	}
+
+	var footer strings.Builder
+	footer.WriteString(promptStyle.Render("Renders as type(scope): title, e.g. feat(auth): add login") + "\n")
+	footer.WriteString(promptStyle.Render("↑/↓ to pick a suggestion, Tab to complete, Enter to confirm (empty for no scope), Esc to go back") + "\n")
+
+	filtered := m.filtered()
+	if len(filtered) == 0 {
+		return header.String() + footer.String()
+	}
+
+	var lines []string
+	for i, s := range filtered {
+		cursor := "  "
+		if m.cursor == i {
+			cursor = "> "
+		}
+
+		note := ""
+		if slices.Contains(m.catalogue, s) {
+			note = "  (configured)"
+		}
+		line := cursor + truncate(s, budget(m.view.width, cursor+note)) + promptStyle.Render(note)
+
+		if m.cursor == i {
+			line = infoStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	// The heading and the blank line after the list
+	lines = m.view.render(lines, m.cursor, m.view.avail(header.String(), footer.String(), "\n\n"))
+
+	return header.String() + "Suggestions:\n" + strings.Join(lines, "\n") + "\n\n" + footer.String()
+}
+
+func (s *Screen) RunScopeSelection(commitType, current string, suggestions, catalogue []string) (string, bool, error) {
+	m, err := s.run(NewScopeModel(commitType, current, suggestions, catalogue))
+	if err != nil {
+		return "", false, err
+	}
+
+	model := m.(*ScopeModel)
+	if model.back {
+		return strings.TrimSpace(model.input), false, ErrBack
+	}
+	if !model.confirmed {
+		return "", false, nil
+	}
+
+	return model.scope, true, nil
+}
@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrBack is returned by the Run methods when the user steps back with
+// Esc or Shift+Tab. Whatever was entered so far is returned along with it
+// where the caller can hand it back on the next visit.
+var ErrBack = errors.New("back to the previous step")
+
+// isBack reports whether a key steps back to the previous step.
+func isBack(msg tea.KeyMsg) bool {
+	return msg.String() == "esc" || msg.String() == "shift+tab"
+}
+
+// Screen is one bubbletea program for a whole session. Each step model is
+// shown in it in turn, so steps replace each other in place instead of
+// starting and tearing down a program each. The Run methods show their
+// step on it; on a nil Screen each step runs in a program of its own.
+type Screen struct {
+	program *tea.Program
+	done    chan tea.Model
+	exited  chan struct{}
+	err     error
+
+	// step counts the steps shown so far. Only the session goroutine
+	// touches it and menu, between steps.
+	step uint64
+	// menu is the model of the previous Run, resumed with its cursor,
+	// expanded hunks and partial selections when the user comes back to
+	// the same changes.
+	menu *Model
+
+	mu       sync.Mutex
+	partial  string // output after the last newline
+	released bool   // the terminal is handed to a subprocess
+}
+
+// showMsg starts step, the gen-th one of the session.
+type showMsg struct {
+	step tea.Model
+	gen  uint64
+}
+
+// stepDoneMsg replaces the tea.QuitMsg of the gen-th step model.
+type stepDoneMsg struct{ gen uint64 }
+
+// hostModel shows the current step model. It is showing a step between a
+// showMsg and the stepDoneMsg of the same generation; a late stepDoneMsg
+// of an earlier step, such as from a second Enter before the first one
+// ended it, is dropped instead of ending the current one.
+type hostModel struct {
+	step tea.Model
+	gen  uint64
+	done chan<- tea.Model
+	size *tea.WindowSizeMsg
+}
+
+func (h *hostModel) Init() tea.Cmd {
+	return nil
+}
+
+func (h *hostModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case showMsg:
+		h.step, h.gen = msg.step, msg.gen
+		cmds := []tea.Cmd{h.step.Init()}
+		if h.size != nil {
+			// A new step hasn't seen the terminal size yet
+			var cmd tea.Cmd
+			h.step, cmd = h.step.Update(*h.size)
+			cmds = append(cmds, cmd)
+		}
+		return h, stepCmd(tea.Batch(cmds...), h.gen)
+
+	case stepDoneMsg:
+		if h.step != nil && msg.gen == h.gen {
+			h.done <- h.step
+			h.step = nil
+		}
+		return h, nil
+
+	case tea.WindowSizeMsg:
+		h.size = &msg
+	}
+
+	if h.step == nil {
+		return h, nil
+	}
+
+	var cmd tea.Cmd
+	h.step, cmd = h.step.Update(msg)
+	return h, stepCmd(cmd, h.gen)
+}
+
+func (h *hostModel) View() string {
+	if h.step == nil {
+		return ""
+	}
+	return h.step.View()
+}
+
+// stepCmd turns the tea.Quit the gen-th step model returns when it is
+// finished into stepDoneMsg, so the step ends rather than the program.
+func stepCmd(cmd tea.Cmd, gen uint64) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.QuitMsg:
+			return stepDoneMsg{gen}
+		case tea.BatchMsg:
+			cmds := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				cmds[i] = stepCmd(c, gen)
+			}
+			return cmds
+		default:
+			return msg
+		}
+	}
+}
+
+// StartScreen starts the session program; the Run methods show their
+// steps on it until Close.
+func StartScreen() *Screen {
+	s := &Screen{
+		done:   make(chan tea.Model),
+		exited: make(chan struct{}),
+	}
+	s.program = tea.NewProgram(&hostModel{done: s.done})
+
+	go func() {
+		_, s.err = s.program.Run()
+		close(s.exited)
+	}()
+
+	return s
+}
+
+// show runs step on the screen until it is finished.
+func (s *Screen) show(step tea.Model) (tea.Model, error) {
+	select {
+	case <-s.exited:
+		return step, s.exitErr()
+	default:
+	}
+
+	s.step++
+	s.program.Send(showMsg{step, s.step})
+
+	select {
+	case m := <-s.done:
+		return m, nil
+	case <-s.exited:
+		return step, s.exitErr()
+	}
+}
+
+func (s *Screen) exitErr() error {
+	if s.err != nil {
+		return s.err
+	}
+	return errors.New("the screen was closed")
+}
+
+// Write prints above the current step, so the screen can be the output of
+// a session or of git; once closed it writes to stdout. Lines are printed
+// when complete, and a carriage return, as in progress output, replaces
+// the line so far.
+func (s *Screen) Write(p []byte) (int, error) {
+	select {
+	case <-s.exited:
+		return os.Stdout.Write(p)
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.released {
+		return os.Stdout.Write(p)
+	}
+
+	lines := strings.Split(s.partial+string(p), "\n")
+	s.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		s.program.Println(lastSegment(line))
+	}
+	return len(p), nil
+}
+
+// lastSegment returns what is left of a line after carriage returns.
+func lastSegment(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	if i := strings.LastIndex(line, "\r"); i >= 0 {
+		return line[i+1:]
+	}
+	return line
+}
+
+// ReleaseTerminal hands the terminal back for a subprocess that may prompt,
+// such as git asking for credentials or a GPG passphrase. Until
+// RestoreTerminal, the screen stops reading keys and writes straight to
+// stdout.
+func (s *Screen) ReleaseTerminal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.released = true
+	return s.program.ReleaseTerminal()
+}
+
+// RestoreTerminal takes the terminal back after ReleaseTerminal.
+func (s *Screen) RestoreTerminal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.released = false
+	return s.program.RestoreTerminal()
+}
+
+// Close stops the program and restores the terminal.
+func (s *Screen) Close() error {
+	s.mu.Lock()
+	if s.partial != "" {
+		s.program.Println(lastSegment(s.partial))
+		s.partial = ""
+	}
+	s.mu.Unlock()
+
+	s.program.Quit()
+	<-s.exited
+	if errors.Is(s.err, tea.ErrProgramKilled) {
+		return nil
+	}
+	return s.err
+}
+
+// run shows m on the screen or, on a nil one, in a program of its own.
+func (s *Screen) run(m tea.Model) (tea.Model, error) {
+	if s != nil {
+		return s.show(m)
+	}
+	return tea.NewProgram(m).Run()
+}
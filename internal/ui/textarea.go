@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var cursorStyle = lipgloss.NewStyle().Reverse(true)
+
+// undoLimit is how many edits TextArea can undo.
+const undoLimit = 100
+
+// TextArea is a multi-line text editor that word-wraps at a fixed width.
+// Its value keeps the wrapping, so what the user sees is what ends up in
+// the commit message; words longer than the width are only broken on
+// screen.
+type TextArea struct {
+	text   []rune
+	cursor int // rune offset into text
+	width  int
+	goal   int // column up/down aim for, -1 for the cursor's own
+
+	undo     []textState
+	lastEdit string // kind of the previous edit, to undo typing word by word
+}
+
+type textState struct {
+	text   []rune
+	cursor int
+}
+
+// visualLine is text[start:end] shown on one screen line. soft marks a
+// line continuing a word that was too long for the previous one.
+type visualLine struct {
+	start, end int
+	soft       bool
+}
+
+func NewTextArea(value string, width int) *TextArea {
+	t := &TextArea{width: width}
+	t.SetValue(value)
+	return t
+}
+
+// SetValue replaces the text, puts the cursor at its end and clears the
+// undo buffer.
+func (t *TextArea) SetValue(value string) {
+	t.text = []rune(value)
+	t.cursor = len(t.text)
+	t.goal = -1
+	t.undo = nil
+	t.lastEdit = ""
+}
+
+// Value returns the text as wrapped on screen, without trailing spaces.
+func (t *TextArea) Value() string {
+	var b strings.Builder
+	for i, l := range t.layout() {
+		if i > 0 && !l.soft {
+			b.WriteString("\n")
+		}
+		b.WriteString(strings.TrimRight(string(t.text[l.start:l.end]), " "))
+	}
+	return b.String()
+}
+
+func (t *TextArea) Empty() bool {
+	return strings.TrimSpace(string(t.text)) == ""
+}
+
+// layout splits the text into screen lines at newlines, after the last
+// space that fits the width, or inside words longer than the width.
+func (t *TextArea) layout() []visualLine {
+	var lines []visualLine
+	for start := 0; start <= len(t.text); {
+		end := start
+		for end < len(t.text) && t.text[end] != '\n' {
+			end++
+		}
+
+		s, soft := start, false
+		for end-s > t.width {
+			cut := s + t.width
+			for cut > s && t.text[cut-1] != ' ' {
+				cut--
+			}
+			nextSoft := false
+			if cut == s {
+				cut, nextSoft = s+t.width, true
+			}
+			lines = append(lines, visualLine{start: s, end: cut, soft: soft})
+			s, soft = cut, nextSoft
+		}
+		lines = append(lines, visualLine{start: s, end: end, soft: soft})
+
+		start = end + 1
+	}
+	return lines
+}
+
+// position returns the screen line and column of the cursor. At a wrap
+// the cursor belongs to the start of the next line.
+func (t *TextArea) position(lines []visualLine) (int, int) {
+	for i, l := range lines {
+		wrapped := i+1 < len(lines) && lines[i+1].start == l.end
+		if t.cursor >= l.start && (t.cursor < l.end || t.cursor == l.end && !wrapped) {
+			return i, t.cursor - l.start
+		}
+	}
+	last := lines[len(lines)-1]
+	return len(lines) - 1, last.end - last.start
+}
+
+// Update applies a key to the text.
+func (t *TextArea) Update(msg tea.KeyMsg) {
+	if msg.String() != "up" && msg.String() != "down" {
+		t.goal = -1
+	}
+
+	switch msg.String() {
+	case "left":
+		t.move(t.cursor - 1)
+	case "right":
+		t.move(t.cursor + 1)
+	case "up":
+		t.moveLine(-1)
+	case "down":
+		t.moveLine(1)
+	case "home", "ctrl+a":
+		t.move(t.lineStart())
+	case "end", "ctrl+e":
+		t.move(t.lineEnd())
+	case "alt+left", "ctrl+left", "alt+b":
+		t.move(t.wordLeft())
+	case "alt+right", "ctrl+right", "alt+f":
+		t.move(t.wordRight())
+
+	case "enter":
+		t.insert([]rune{'\n'})
+	case "backspace":
+		t.delete(t.cursor-1, t.cursor, "backspace")
+	case "delete":
+		t.delete(t.cursor, t.cursor+1, "delete")
+	case "ctrl+w", "alt+backspace":
+		t.delete(t.wordLeft(), t.cursor, "word")
+	case "alt+delete", "alt+d":
+		t.delete(t.cursor, t.wordRight(), "word")
+	case "ctrl+u":
+		t.delete(t.lineStart(), t.cursor, "line")
+	case "ctrl+k":
+		t.delete(t.cursor, t.lineEnd(), "line")
+	case "ctrl+z":
+		t.restore()
+
+	default:
+		if msg.Alt {
+			return
+		}
+		switch msg.Type {
+		case tea.KeyRunes, tea.KeySpace:
+			t.insert(sanitize(msg.Runes))
+		}
+	}
+}
+
+// sanitize normalizes typed or pasted runes: CRLF and CR become newlines,
+// tabs four spaces, and other control characters are dropped.
+func sanitize(runes []rune) []rune {
+	s := strings.ReplaceAll(string(runes), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.ReplaceAll(s, "\t", "    ")
+
+	var res []rune
+	for _, r := range s {
+		if r == '\n' || !unicode.IsControl(r) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+func (t *TextArea) move(to int) {
+	t.cursor = max(0, min(to, len(t.text)))
+	t.lastEdit = ""
+}
+
+func (t *TextArea) moveLine(delta int) {
+	lines := t.layout()
+	row, col := t.position(lines)
+	if t.goal < 0 {
+		t.goal = col
+	}
+
+	target := row + delta
+	switch {
+	case target < 0:
+		t.move(0)
+		return
+	case target >= len(lines):
+		t.move(len(t.text))
+		return
+	}
+
+	l := lines[target]
+	limit := l.end
+	if target+1 < len(lines) && lines[target+1].start == l.end && limit > l.start {
+		// Stay on this line rather than jump to the start of the next
+		limit--
+	}
+	t.move(min(l.start+t.goal, limit))
+}
+
+func (t *TextArea) lineStart() int {
+	lines := t.layout()
+	row, _ := t.position(lines)
+	return lines[row].start
+}
+
+func (t *TextArea) lineEnd() int {
+	lines := t.layout()
+	row, _ := t.position(lines)
+	return lines[row].end
+}
+
+func (t *TextArea) wordLeft() int {
+	i := t.cursor
+	for i > 0 && unicode.IsSpace(t.text[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(t.text[i-1]) {
+		i--
+	}
+	return i
+}
+
+func (t *TextArea) wordRight() int {
+	i := t.cursor
+	for i < len(t.text) && !unicode.IsSpace(t.text[i]) {
+		i++
+	}
+	for i < len(t.text) && unicode.IsSpace(t.text[i]) {
+		i++
+	}
+	return i
+}
+
+// record saves the text for undo unless the edit continues the previous
+// one of the same kind.
+func (t *TextArea) record(kind string) {
+	if kind == t.lastEdit {
+		return
+	}
+	t.undo = append(t.undo, textState{text: slices.Clone(t.text), cursor: t.cursor})
+	if len(t.undo) > undoLimit {
+		t.undo = t.undo[1:]
+	}
+	t.lastEdit = kind
+}
+
+func (t *TextArea) insert(runes []rune) {
+	if len(runes) == 0 {
+		return
+	}
+
+	kind := "type"
+	if len(runes) > 1 {
+		kind = "paste"
+		t.lastEdit = ""
+	}
+	t.record(kind)
+
+	t.text = slices.Insert(t.text, t.cursor, runes...)
+	t.cursor += len(runes)
+
+	// Each word typed is undone on its own
+	if kind == "paste" || unicode.IsSpace(runes[len(runes)-1]) {
+		t.lastEdit = ""
+	}
+}
+
+func (t *TextArea) delete(from, to int, kind string) {
+	from, to = max(0, from), min(to, len(t.text))
+	if from >= to {
+		return
+	}
+	t.record(kind)
+	t.text = slices.Delete(t.text, from, to)
+	t.cursor = from
+}
+
+func (t *TextArea) restore() {
+	if len(t.undo) == 0 {
+		return
+	}
+	last := t.undo[len(t.undo)-1]
+	t.undo = t.undo[:len(t.undo)-1]
+	t.text, t.cursor = last.text, last.cursor
+	t.lastEdit = ""
+}
+
+// View renders the wrapped text with the cursor.
+func (t *TextArea) View() string {
+	lines := t.layout()
+	row, col := t.position(lines)
+
+	var b strings.Builder
+	for i, l := range lines {
+		line := t.text[l.start:l.end]
+		if i != row {
+			b.WriteString(string(line) + "\n")
+			continue
+		}
+
+		b.WriteString(string(line[:col]))
+		if col < len(line) {
+			b.WriteString(cursorStyle.Render(string(line[col])) + string(line[col+1:]))
+		} else {
+			b.WriteString(cursorStyle.Render(" "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gcm/internal/model"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TrailerSuggestions feed the trailers step of the commit message.
+type TrailerSuggestions struct {
+	CoAuthors []string // "Name <email>" of recent authors
+	IssueRefs []string // issue keys inferred from the branch name
+	SignOff   string   // "Name <email>" of the committer, empty if unknown
+}
+
+// Keys that start adding a trailer in the trailers step
+var trailerKeys = map[string]string{
+	"r": model.TrailerRefs,
+	"c": model.TrailerCloses,
+	"a": model.TrailerCoAuthor,
+}
+
+// completions returns the candidates for the trailer being typed.
+func (m *CommitMessageModel) completions() []string {
+	var candidates []string
+	switch m.trailerToken {
+	case model.TrailerCoAuthor:
+		candidates = m.suggestions.CoAuthors
+	case model.TrailerRefs, model.TrailerCloses:
+		candidates = m.suggestions.IssueRefs
+	}
+
+	query := strings.ToLower(m.trailerInput)
+	var res []string
+	for _, c := range candidates {
+		if strings.Contains(strings.ToLower(c), query) {
+			res = append(res, c)
+		}
+		if len(res) == 5 {
+			break
+		}
+	}
+	return res
+}
+
+func (m *CommitMessageModel) updateTrailers(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.trailerToken != "" {
+		return m.updateTrailerInput(msg)
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.mode = "description"
+		if m.info.Breaking {
+			m.mode = "breaking"
+		}
+		m.trailerErr = ""
+
+	case "enter":
+		m.mode = "preview"
+		m.trailerErr = ""
+
+	case "up", "k":
+		if m.trailerCursor > 0 {
+			m.trailerCursor--
+		}
+
+	case "down", "j":
+		if m.trailerCursor < len(m.trailers)-1 {
+			m.trailerCursor++
+		}
+
+	case "r", "c", "a":
+		m.trailerToken = trailerKeys[msg.String()]
+		m.trailerInput = ""
+		m.trailerPick = -1
+		if m.trailerToken != model.TrailerCoAuthor && len(m.completions()) > 0 {
+			// The branch's issue is one Enter away
+			m.trailerPick = 0
+		}
+		m.trailerErr = ""
+
+	case "s":
+		signOff := model.Trailer{Token: model.TrailerSignedOff, Value: m.suggestions.SignOff}
+		if i := slices.Index(m.trailers, signOff); i >= 0 {
+			m.trailers = slices.Delete(m.trailers, i, i+1)
+		} else if m.suggestions.SignOff == "" {
+			m.trailerErr = "set git config user.name and user.email to sign off"
+		} else {
+			m.trailers = append(m.trailers, signOff)
+		}
+		m.trailerCursor = min(m.trailerCursor, max(len(m.trailers)-1, 0))
+
+	case "x", "backspace", "delete":
+		if len(m.trailers) > 0 {
+			m.trailers = slices.Delete(m.trailers, m.trailerCursor, m.trailerCursor+1)
+			m.trailerCursor = min(m.trailerCursor, max(len(m.trailers)-1, 0))
+		}
+	}
+
+	return m, nil
+}
+
+func (m *CommitMessageModel) updateTrailerInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	completions := m.completions()
+
+	switch msg.String() {
+	case "esc":
+		m.trailerToken = ""
+		m.trailerErr = ""
+
+	case "up":
+		if m.trailerPick >= 0 {
+			m.trailerPick--
+		}
+
+	case "down":
+		if m.trailerPick < len(completions)-1 {
+			m.trailerPick++
+		}
+
+	case "tab":
+		if m.trailerPick >= 0 {
+			m.trailerInput = completions[m.trailerPick]
+		} else if len(completions) > 0 {
+			m.trailerInput = completions[0]
+		}
+		m.trailerPick = -1
+
+	case "enter":
+		value := strings.TrimSpace(m.trailerInput)
+		if m.trailerPick >= 0 {
+			value = completions[m.trailerPick]
+		}
+		t := model.Trailer{Token: m.trailerToken, Value: value}
+		if err := model.ValidateTrailer(t); err != nil {
+			m.trailerErr = err.Error()
+			return m, nil
+		}
+		m.trailers = append(m.trailers, t)
+		m.trailerCursor = len(m.trailers) - 1
+		m.trailerToken = ""
+		m.trailerErr = ""
+
+	case "backspace":
+		if len(m.trailerInput) > 0 {
+			m.trailerInput = m.trailerInput[:len(m.trailerInput)-1]
+			m.trailerPick = -1
+			m.trailerErr = ""
+		}
+
+	default:
+		if len(msg.String()) == 1 {
+			m.trailerInput += msg.String()
+			m.trailerPick = -1
+			m.trailerErr = ""
+		}
+	}
+
+	return m, nil
+}
+
+func (m *CommitMessageModel) viewTrailers(b *strings.Builder) {
+	b.WriteString(titleStyle.Render("🔗 Trailers (Optional)") + "\n\n")
+	b.WriteString(m.commitInfo().Header() + "\n\n")
+
+	if len(m.trailers) == 0 {
+		b.WriteString(promptStyle.Render("(no trailers)") + "\n\n")
+		if refs := m.suggestions.IssueRefs; len(refs) > 0 && m.trailerToken == "" {
+			hint := fmt.Sprintf("The branch mentions %s: 'r' or 'c' to reference it", strings.Join(refs, ", "))
+			b.WriteString(infoStyle.Render(truncate(hint, m.view.width)) + "\n\n")
+		}
+	} else {
+		for i, t := range m.trailers {
+			cursor := "  "
+			if m.trailerToken == "" && m.trailerCursor == i {
+				cursor = "> "
+			}
+			line := cursor + truncate(t.String(), m.view.width-2)
+			if m.trailerToken == "" && m.trailerCursor == i {
+				b.WriteString(infoStyle.Render(line) + "\n")
+			} else {
+				b.WriteString(line + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if m.trailerToken != "" {
+		b.WriteString(fmt.Sprintf("%s: %s_\n\n", m.trailerToken, m.trailerInput))
+		for i, c := range m.completions() {
+			cursor := "  "
+			if m.trailerPick == i {
+				cursor = "> "
+			}
+			if m.trailerPick == i {
+				b.WriteString(infoStyle.Render(cursor+truncate(c, m.view.width-2)) + "\n")
+			} else {
+				b.WriteString(promptStyle.Render(cursor+truncate(c, m.view.width-2)) + "\n")
+			}
+		}
+	}
+
+	if m.trailerErr != "" {
+		b.WriteString("\n" + errorStyle.Render("❌ "+m.trailerErr) + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.trailerToken != "" {
+		b.WriteString(promptStyle.Render("↑/↓ to pick a suggestion, Tab to complete, Enter to add, Esc to cancel") + "\n")
+		return
+	}
+	b.WriteString(promptStyle.Render("'r' Refs, 'c' Closes, 'a' Co-authored-by, 's' toggle Signed-off-by, 'x' remove") + "\n")
+	b.WriteString(promptStyle.Render("Press Enter to continue, Esc to go back") + "\n")
+}
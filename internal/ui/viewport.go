@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// viewport fits a model into the terminal: it keeps the size reported by
+// tea.WindowSizeMsg and scrolls a list so that its cursor stays on screen.
+// Until the first size arrives nothing is cut.
+type viewport struct {
+	width  int
+	height int
+	offset int // first list line shown
+	page   int // list lines shown by the last render
+}
+
+func (v *viewport) resize(msg tea.WindowSizeMsg) {
+	v.width, v.height = msg.Width, msg.Height
+}
+
+// avail returns the lines left for a list next to chrome, the rendered
+// text around it, with a floor so that a tiny terminal still shows some.
+func (v *viewport) avail(chrome ...string) int {
+	if v.height == 0 {
+		return -1
+	}
+
+	used := 0
+	for _, c := range chrome {
+		used += screenLines(c, v.width)
+	}
+	return max(v.height-used, 3)
+}
+
+// screenLines counts the terminal lines s takes at width columns, long
+// lines wrapping.
+func screenLines(s string, width int) int {
+	if s == "" {
+		return 0
+	}
+
+	count := 0
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		count++
+		if w := lipgloss.Width(line); width > 0 && w > width {
+			count += (w - 1) / width
+		}
+	}
+	return count
+}
+
+// render returns the lines fitting in avail terminal lines (all of them
+// for a negative avail), scrolled to show line cursor, or to keep the
+// offset when cursor is negative. A last line tells where the window is
+// when the list doesn't fit.
+func (v *viewport) render(lines []string, cursor, avail int) []string {
+	if avail < 0 || len(lines) <= avail {
+		v.offset, v.page = 0, len(lines)
+		return lines
+	}
+
+	size := max(avail-1, 1)
+	v.page = size
+	if cursor >= 0 {
+		if cursor < v.offset {
+			v.offset = cursor
+		}
+		if cursor >= v.offset+size {
+			v.offset = cursor - size + 1
+		}
+	}
+	v.offset = max(0, min(v.offset, len(lines)-size))
+
+	res := slices.Clone(lines[v.offset : v.offset+size])
+	note := fmt.Sprintf("  lines %d-%d of %d · PgUp/PgDn, g/G", v.offset+1, v.offset+size, len(lines))
+	return append(res, promptStyle.Render(note))
+}
+
+// pageMove returns where PgUp/PgDn, g/G and Home/End move a cursor over
+// count rows, a page being the rows shown last; ok is false for other
+// keys.
+func (v *viewport) pageMove(key string, cursor, count int) (int, bool) {
+	page := max(v.page-1, 1)
+	switch key {
+	case "pgup":
+		cursor -= page
+	case "pgdown":
+		cursor += page
+	case "g", "home":
+		cursor = 0
+	case "G", "end":
+		cursor = count - 1
+	default:
+		return cursor, false
+	}
+	return max(0, min(cursor, count-1)), true
+}
+
+// truncate cuts plain text to width columns, ending it with an ellipsis.
+// A width of zero or less leaves it whole.
+func truncate(s string, width int) string {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return s
+	}
+
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// truncateStart returns the byte offset to show plain text from so that,
+// behind an ellipsis, it fits width columns. Paths are cut this way as
+// their end names the file.
+func truncateStart(s string, width int) int {
+	if width <= 0 || lipgloss.Width(s) <= width {
+		return 0
+	}
+
+	for i := range s {
+		if i > 0 && lipgloss.Width(s[i:])+1 <= width {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// truncatePath cuts a path at the start with an ellipsis to fit width
+// columns.
+func truncatePath(p string, width int) string {
+	if from := truncateStart(p, width); from > 0 {
+		return "…" + p[from:]
+	}
+	return p
+}
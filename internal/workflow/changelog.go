@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"gcm/internal/changelog"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/semver"
+)
+
+// ChangelogOptions select the commits of `gcm changelog` and how the
+// result is written.
+type ChangelogOptions struct {
+	From    string // start tag; defaults to the latest version tag before To
+	To      string // defaults to HEAD
+	Version string // defaults to To when it is a version tag, else Unreleased
+	All     bool   // include every commit type, not only user-facing ones
+
+	JSON    bool
+	Prepend bool   // write the release into File instead of printing it
+	File    string // defaults to CHANGELOG.md
+}
+
+// RunChangelog renders the conventional commits between two tags as a
+// changelog release.
+func RunChangelog(repo gitpkg.Repository, cfg config.Config, opts ChangelogOptions, out io.Writer) error {
+	if opts.To == "" {
+		opts.To = "HEAD"
+	}
+	if opts.File == "" {
+		opts.File = "CHANGELOG.md"
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return exitError(ExitGit, "listing tags: %v", err)
+	}
+
+	toVersion, toErr := semver.Parse(opts.To)
+	if opts.Version == "" {
+		opts.Version = changelog.Unreleased
+		if toErr == nil {
+			opts.Version = strings.TrimPrefix(toVersion.String(), "v")
+		}
+	}
+
+	if opts.From == "" {
+		// The latest version tag, or the one before To if To is a tag
+		var names []string
+		for _, t := range tags {
+			v, err := semver.Parse(t.Name)
+			if err == nil && (toErr != nil || semver.Compare(v, toVersion) < 0) {
+				names = append(names, t.Name)
+			}
+		}
+		opts.From, _, _ = semver.Latest(names, false)
+	}
+
+	revRange := opts.To
+	if opts.From != "" {
+		revRange = opts.From + ".." + opts.To
+	}
+
+	commits, err := repo.Log(revRange)
+	if err != nil {
+		return exitError(ExitGit, "listing commits: %v", err)
+	}
+
+	date := ""
+	if opts.Version != changelog.Unreleased {
+		// The day To was tagged, or today for a release not tagged yet
+		when := time.Now()
+		for _, t := range tags {
+			if t.Name == opts.To && !t.Date.IsZero() {
+				when = t.Date
+			}
+		}
+		date = when.Format("2006-01-02")
+	}
+	release := changelog.Build(opts.Version, date, commits, cfg.Types, opts.All)
+
+	if release.IsEmpty() {
+		return exitError(ExitNothing, "no conventional commits in %s", revRange)
+	}
+
+	switch {
+	case opts.JSON:
+		data, err := json.MarshalIndent(release, "", "  ")
+		if err != nil {
+			return exitError(ExitGit, "%v", err)
+		}
+		fmt.Fprintln(out, string(data))
+
+	case opts.Prepend:
+		existing, err := os.ReadFile(opts.File)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return exitError(ExitGit, "reading %s: %v", opts.File, err)
+		}
+		updated := changelog.Prepend(string(existing), release)
+		if err := os.WriteFile(opts.File, []byte(updated), 0o644); err != nil {
+			return exitError(ExitGit, "writing %s: %v", opts.File, err)
+		}
+		fmt.Fprintf(out, "✓ Added [%s] to %s (%s)\n", release.Version, opts.File, revRange)
+
+	default:
+		fmt.Fprint(out, release.Markdown())
+	}
+
+	return nil
+}
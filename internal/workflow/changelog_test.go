@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gcm/internal/changelog"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+)
+
+func TestRunChangelog(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	tags := []gitpkg.Tag{
+		{Name: "v1.0.0", Date: time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)},
+		{Name: "not-a-version"},
+		{Name: "v1.1.0", Date: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name    string
+		tags    []gitpkg.Tag
+		opts    ChangelogOptions
+		logged  string
+		version string
+		date    string
+	}{
+		{name: "no tags", logged: "HEAD", version: changelog.Unreleased},
+		{name: "since the latest tag", tags: tags, logged: "v1.1.0..HEAD", version: changelog.Unreleased},
+		{name: "a tagged version", tags: tags, opts: ChangelogOptions{To: "v1.1.0"}, logged: "v1.0.0..v1.1.0", version: "1.1.0", date: "2026-10-01"},
+		{name: "the first tag", tags: tags, opts: ChangelogOptions{To: "v1.0.0"}, logged: "v1.0.0", version: "1.0.0", date: "2026-09-01"},
+		{name: "a version not tagged yet", tags: tags, opts: ChangelogOptions{To: "v1.2.0"}, logged: "v1.1.0..v1.2.0", version: "1.2.0", date: today},
+		{name: "a given start", tags: tags, opts: ChangelogOptions{From: "v1.0.0"}, logged: "v1.0.0..HEAD", version: changelog.Unreleased},
+		{name: "a given version", tags: tags, opts: ChangelogOptions{Version: "2.0.0"}, logged: "v1.1.0..HEAD", version: "2.0.0", date: today},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := gitpkg.NewFakeRepository("main")
+			repo.TagList = tt.tags
+			repo.Commits = []gitpkg.FakeCommit{{Message: "fix(cli): handle empty input"}}
+
+			var out strings.Builder
+			tt.opts.JSON = true
+			if err := RunChangelog(repo, config.Default(), tt.opts, &out); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(repo.Logged) != 1 || repo.Logged[0] != tt.logged {
+				t.Errorf("logged %v, want %s", repo.Logged, tt.logged)
+			}
+			var release changelog.Release
+			if err := json.Unmarshal([]byte(out.String()), &release); err != nil {
+				t.Fatal(err)
+			}
+			if release.Version != tt.version || release.Date != tt.date {
+				t.Errorf("release = %s %q, want %s %q", release.Version, release.Date, tt.version, tt.date)
+			}
+		})
+	}
+}
+
+func TestRunChangelogPrepend(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("main")
+	repo.Commits = []gitpkg.FakeCommit{{Message: "fix(cli): handle empty input"}, {Message: "update the readme"}}
+	file := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	var out strings.Builder
+	if err := RunChangelog(repo, config.Default(), ChangelogOptions{Prepend: true, File: file}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Changelog\n\n## [Unreleased]\n\n### Bug Fixes\n- **cli:** handle empty input (0000000)\n"; string(data) != want {
+		t.Errorf("%s =\n%s\nwant\n%s", file, data, want)
+	}
+}
+
+func TestRunChangelogNothing(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("main")
+	repo.Commits = []gitpkg.FakeCommit{{Message: "update the readme"}}
+
+	err := RunChangelog(repo, config.Default(), ChangelogOptions{}, &strings.Builder{})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitNothing {
+		t.Fatalf("err = %v, want exit code %d", err, ExitNothing)
+	}
+}
@@ -0,0 +1,208 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+)
+
+// Hooks gcm installs
+const (
+	HookCommitMsg        = "commit-msg"
+	HookPrepareCommitMsg = "prepare-commit-msg"
+)
+
+var hooks = []string{HookPrepareCommitMsg, HookCommitMsg}
+
+// hookMarker identifies hook scripts written by gcm, so they are the only
+// ones overwritten or removed.
+const hookMarker = "# Installed by gcm"
+
+func hookScript(exe, hook string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s; remove with `gcm hook uninstall`.\nexec '%s' hook run %s \"$@\"\n",
+		hookMarker, strings.ReplaceAll(exe, "'", `'\''`), hook)
+}
+
+func isGcmHook(file string) (bool, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(data), hookMarker), nil
+}
+
+// InstallHooks writes hook scripts calling exe into the repository's hooks
+// directory. Hooks not written by gcm are only replaced with force.
+func InstallHooks(repo gitpkg.Repository, exe string, force bool, out io.Writer) error {
+	dir, err := repo.HooksDir()
+	if err != nil {
+		return exitError(ExitGit, "finding hooks directory: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return exitError(ExitGit, "%v", err)
+	}
+
+	// Check every hook first so nothing is installed half-way
+	for _, hook := range hooks {
+		file := filepath.Join(dir, hook)
+
+		ours, err := isGcmHook(file)
+		if err != nil {
+			return exitError(ExitGit, "%v", err)
+		}
+		if _, err := os.Stat(file); err == nil && !ours && !force {
+			return exitError(ExitInvalid, "%s already exists and wasn't installed by gcm, pass --force to replace it", file)
+		}
+	}
+
+	for _, hook := range hooks {
+		file := filepath.Join(dir, hook)
+		if err := os.WriteFile(file, []byte(hookScript(exe, hook)), 0o755); err != nil {
+			return exitError(ExitGit, "writing %s: %v", hook, err)
+		}
+		fmt.Fprintf(out, "✓ Installed %s\n", file)
+	}
+	return nil
+}
+
+// UninstallHooks removes the hook scripts written by gcm.
+func UninstallHooks(repo gitpkg.Repository, out io.Writer) error {
+	dir, err := repo.HooksDir()
+	if err != nil {
+		return exitError(ExitGit, "finding hooks directory: %v", err)
+	}
+
+	for _, hook := range hooks {
+		file := filepath.Join(dir, hook)
+
+		ours, err := isGcmHook(file)
+		if err != nil {
+			return exitError(ExitGit, "%v", err)
+		}
+		if !ours {
+			continue
+		}
+
+		if err := os.Remove(file); err != nil {
+			return exitError(ExitGit, "removing %s: %v", hook, err)
+		}
+		fmt.Fprintf(out, "✓ Removed %s\n", file)
+	}
+	return nil
+}
+
+// CommentChar returns the comment character git strips from edited
+// messages: core.commentString or core.commentChar, "#" by default. It may
+// be "auto", which model.CleanMessage resolves.
+func CommentChar(repo gitpkg.Repository) string {
+	for _, key := range []string{"core.commentString", "core.commentChar"} {
+		if values, _ := repo.ConfigValues(key); len(values) > 0 {
+			return values[len(values)-1]
+		}
+	}
+	return "#"
+}
+
+// RunCommitMsgHook lints the message git is about to commit and fails on
+// errors, which makes git abort the commit. Comments, in commentChar, and
+// anything below the scissors line are not part of the message.
+func RunCommitMsgHook(linter *lint.Engine, file, commentChar string, out io.Writer) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return exitError(ExitUsage, "reading message: %v", err)
+	}
+
+	msg := string(data)
+	info := model.ParseEditedMessage(msg, commentChar)
+	if lint.Ignored(msg) || info.Title == "" && info.Type == "" {
+		// git rejects empty messages on its own
+		return nil
+	}
+
+	violations := linter.Lint(info)
+	for _, v := range violations {
+		if v.Severity == lint.Error {
+			fmt.Fprintf(out, "❌ %s\n", v)
+		} else {
+			fmt.Fprintf(out, "⚠️  %s\n", v)
+		}
+	}
+
+	if lint.HasErrors(violations) {
+		return exitError(ExitInvalid, "commit message rejected by gcm, see `gcm lint --file`")
+	}
+	return nil
+}
+
+// RunPrepareCommitMsgHook pre-fills an empty message with a conventional
+// header whose type and scope are inferred from the staged changes. source
+// is the second hook argument; messages from -m, -F, templates, merges,
+// squashes and amends are left alone.
+func RunPrepareCommitMsgHook(repo gitpkg.Repository, cfg config.Config, file, source string) error {
+	if source != "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return exitError(ExitUsage, "reading message: %v", err)
+	}
+	commentChar := CommentChar(repo)
+	if info := model.ParseEditedMessage(string(data), commentChar); info.Title != "" || info.Type != "" {
+		return nil
+	}
+
+	status, err := repo.Status()
+	if err != nil {
+		return exitError(ExitGit, "checking changed files: %v", err)
+	}
+	staged := changes.Staged(changes.SplitStaged(status))
+
+	var b strings.Builder
+
+	commitType, reason := changes.SuggestType(staged, cfg.TypeRules, func(c model.GitChange) (string, error) {
+		return repo.Diff(c, true)
+	})
+	if commitType != "" {
+		header := commitType
+		if scopes := changes.SuggestScopes(staged); len(scopes) > 0 {
+			header += "(" + scopes[0] + ")"
+		}
+		b.WriteString(header + ": \n")
+	} else {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	// With core.commentChar auto git picks the character itself, and hints
+	// in another one would end up in the commit
+	if commentChar != "auto" {
+		if reason != "" {
+			fmt.Fprintf(&b, "%s gcm: type suggested because %s.\n", commentChar, reason)
+		}
+		types := make([]string, len(cfg.Types))
+		for i, t := range cfg.Types {
+			types[i] = t.Key
+		}
+		fmt.Fprintf(&b, "%s Format: type(scope): subject, with a type among %s.\n", commentChar, strings.Join(types, ", "))
+	}
+	b.Write(data)
+
+	if err := os.WriteFile(file, []byte(b.String()), 0o644); err != nil {
+		return exitError(ExitGit, "writing message: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+)
+
+// LintOptions select the messages `gcm lint` checks: a message file ("-"
+// for stdin) or else a revision range.
+type LintOptions struct {
+	Range string
+	File  string
+}
+
+// RunLint checks existing commit messages with the same rules as the
+// interactive flow and prints the violations per commit. It fails with
+// ExitInvalid if any message has errors.
+func RunLint(repo gitpkg.Repository, linter *lint.Engine, opts LintOptions, in io.Reader, out io.Writer) error {
+	var commits []gitpkg.Commit
+	commentChar := "#"
+
+	switch {
+	case opts.File != "":
+		var data []byte
+		var err error
+		if opts.File == "-" {
+			data, err = io.ReadAll(in)
+		} else {
+			data, err = os.ReadFile(opts.File)
+		}
+		if err != nil {
+			return exitError(ExitUsage, "reading message: %v", err)
+		}
+		commits = []gitpkg.Commit{{Message: string(data)}}
+		// A message file may come from the editor, with git's comments
+		commentChar = CommentChar(repo)
+
+	case opts.Range != "":
+		var err error
+		commits, err = repo.Log(opts.Range)
+		if err != nil {
+			return exitError(ExitGit, "listing commits: %v", err)
+		}
+
+	default:
+		return exitError(ExitUsage, "pass a revision range (e.g. origin/main..HEAD) or --file")
+	}
+
+	checked, errorCount, warningCount := 0, 0, 0
+	for _, c := range commits {
+		if lint.Ignored(c.Message) {
+			continue
+		}
+		checked++
+
+		info := model.ParseEditedMessage(c.Message, commentChar)
+		violations := linter.Lint(info)
+
+		name := "message"
+		if c.Hash != "" {
+			name = c.Hash[:min(7, len(c.Hash))]
+		}
+		header, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+
+		if len(violations) == 0 {
+			fmt.Fprintf(out, "%s %s %s\n", successStyle.Render("✓"), name, header)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s %s %s\n", warningStyle.Render("⧗"), name, header)
+		for _, v := range violations {
+			if v.Severity == lint.Error {
+				errorCount++
+				fmt.Fprintf(out, "    ❌ %s\n", v)
+			} else {
+				warningCount++
+				fmt.Fprintf(out, "    ⚠️  %s\n", v)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d commit(s) checked, %d error(s), %d warning(s)\n", checked, errorCount, warningCount)
+
+	if errorCount > 0 {
+		return exitError(ExitInvalid, "%d error(s) in commit messages", errorCount)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+	"gcm/internal/ui"
+)
+
+// Prompter asks the user for every decision the commit session needs.
+// Any step may return ui.ErrBack to go back to the previous one; the
+// arguments carrying an earlier answer (chosen, selected, current, info)
+// restore it on the next visit.
+type Prompter interface {
+	SelectBranch(currentBranch, chosen string, isMainBranch bool) (string, bool, error)
+	SelectFiles(items, selected []model.GitChange, loadDiff ui.DiffFunc) ([]model.GitChange, error)
+	ReviewGroups(groups []changes.Group) ([]changes.Group, bool, error)
+	SelectCommitType(suggested, reason string) (string, bool, error)
+	Input(prompt string) (string, bool, error)
+	SelectScope(commitType, current string, suggestions, catalogue []string) (string, bool, error)
+	CommitMessage(info model.CommitInfo, suggestions ui.TrailerSuggestions) (model.CommitInfo, bool, error)
+	Confirm(prompt string) (bool, error)
+	ConfirmRelease(current, next, reason string, commits []string) (bool, error)
+}
+
+// Terminal prompts through the bubbletea programs of the ui package,
+// applying the rules of Config and checking messages with Lint. Steps are
+// shown on Screen, or each in a program of its own when it is nil.
+type Terminal struct {
+	Config config.Config
+	Lint   *lint.Engine
+	Screen *ui.Screen
+}
+
+func (t Terminal) SelectBranch(currentBranch, chosen string, isMainBranch bool) (string, bool, error) {
+	return t.Screen.RunBranchSelection(currentBranch, chosen, isMainBranch, t.Config.Branch)
+}
+
+func (t Terminal) SelectFiles(items, selected []model.GitChange, loadDiff ui.DiffFunc) ([]model.GitChange, error) {
+	return t.Screen.Run(items, selected, loadDiff, t.Config.LargeFileBytes)
+}
+
+func (t Terminal) ReviewGroups(groups []changes.Group) ([]changes.Group, bool, error) {
+	return t.Screen.RunGroupReview(groups)
+}
+
+func (t Terminal) SelectCommitType(suggested, reason string) (string, bool, error) {
+	return t.Screen.RunCommitTypeSelection(t.Config.Types, suggested, reason)
+}
+
+func (t Terminal) Input(prompt string) (string, bool, error) {
+	return t.Screen.GetInput(prompt)
+}
+
+func (t Terminal) SelectScope(commitType, current string, suggestions, catalogue []string) (string, bool, error) {
+	return t.Screen.RunScopeSelection(commitType, current, suggestions, catalogue)
+}
+
+func (t Terminal) CommitMessage(info model.CommitInfo, suggestions ui.TrailerSuggestions) (model.CommitInfo, bool, error) {
+	return t.Screen.RunCommitMessage(info, suggestions, t.Config.Title, t.Lint)
+}
+
+func (t Terminal) Confirm(prompt string) (bool, error) {
+	return t.Screen.Confirm(prompt, "(y/n)")
+}
+
+func (t Terminal) ConfirmRelease(current, next, reason string, commits []string) (bool, error) {
+	return t.Screen.RunReleaseConfirm(current, next, reason, commits)
+}
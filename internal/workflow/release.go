@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gcm/internal/changelog"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+	"gcm/internal/semver"
+)
+
+var preChannel = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// ReleaseOptions configure `gcm release`.
+type ReleaseOptions struct {
+	Pre    string // pre-release channel, e.g. "rc" for v1.2.0-rc.1
+	Sign   bool
+	Push   bool
+	Yes    bool // skip the confirmation
+	DryRun bool // only print the next version
+}
+
+// NextVersion computes the release after the latest stable version tag
+// from the conventional commits since then. A pre-release gets the next
+// free number of its channel. The level is None when nothing warrants a
+// release.
+func NextVersion(tags []gitpkg.Tag, commits []gitpkg.Commit, pre string) (semver.Version, semver.Level) {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	_, base, _ := semver.Latest(names, true)
+
+	level := semver.None
+	for _, c := range commits {
+		if lint.Ignored(c.Message) {
+			continue
+		}
+		info := model.ParseMessage(c.Message)
+		switch {
+		case info.Breaking || info.BreakingChange != "":
+			level = max(level, semver.Major)
+		case info.Type == "feat":
+			level = max(level, semver.Minor)
+		case info.Type == "fix" || info.Type == "perf":
+			level = max(level, semver.Patch)
+		}
+	}
+
+	next := base.Bump(level)
+	if pre == "" || level == semver.None {
+		return next, level
+	}
+
+	// v1.2.0-rc.1 and v1.2.0-rc.2 exist: the next one is rc.3
+	n := 0
+	prefix := next.String() + "-" + pre + "."
+	for _, name := range names {
+		if num, ok := strings.CutPrefix(name, prefix); ok {
+			if i, err := strconv.Atoi(num); err == nil {
+				n = max(n, i)
+			}
+		}
+	}
+	next.Pre = fmt.Sprintf("%s.%d", pre, n+1)
+	return next, level
+}
+
+var levelReasons = map[semver.Level]string{
+	semver.Major: "major: breaking changes",
+	semver.Minor: "minor: new features",
+	semver.Patch: "patch: fixes and performance improvements",
+}
+
+// RunRelease tags the next semantic version after confirmation and
+// optionally pushes the tag.
+func RunRelease(repo gitpkg.Repository, cfg config.Config, prompter Prompter, opts ReleaseOptions, out io.Writer) error {
+	if opts.Pre != "" && !preChannel.MatchString(opts.Pre) {
+		return exitError(ExitUsage, "--pre must be a single identifier such as rc or beta, got %q", opts.Pre)
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return exitError(ExitGit, "listing tags: %v", err)
+	}
+
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	current, _, _ := semver.Latest(names, true)
+
+	revRange := "HEAD"
+	if current != "" {
+		revRange = current + "..HEAD"
+	}
+	commits, err := repo.Log(revRange)
+	if err != nil {
+		return exitError(ExitGit, "listing commits: %v", err)
+	}
+
+	next, level := NextVersion(tags, commits, opts.Pre)
+	if level == semver.None {
+		return exitError(ExitNothing, "no features, fixes or breaking changes in %s", revRange)
+	}
+	tag := next.String()
+	for _, name := range names {
+		if name == tag {
+			return exitError(ExitInvalid, "tag %s already exists", tag)
+		}
+	}
+
+	lines := make([]string, len(commits))
+	for i, c := range commits {
+		header, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		lines[i] = fmt.Sprintf("%s %s", c.Hash[:min(7, len(c.Hash))], header)
+	}
+
+	if opts.DryRun {
+		fmt.Fprintf(out, "%s (%s)\n", tag, levelReasons[level])
+		for _, l := range lines {
+			fmt.Fprintf(out, "  %s\n", l)
+		}
+		return nil
+	}
+
+	if !opts.Yes {
+		ok, err := prompter.ConfirmRelease(current, tag, levelReasons[level], lines)
+		if err != nil {
+			return exitError(ExitGit, "running UI: %v", err)
+		}
+		if !ok {
+			return exitError(ExitAborted, "release aborted")
+		}
+	}
+
+	version := strings.TrimPrefix(tag, "v")
+	release := changelog.Build(version, time.Now().Format("2006-01-02"), commits, cfg.Types, false)
+	message := fmt.Sprintf("Release %s\n\n%s", tag, release.Text())
+
+	if err := repo.CreateTag(tag, message, opts.Sign); err != nil {
+		return exitError(ExitGit, "creating tag: %v", err)
+	}
+	fmt.Fprintln(out, successStyle.Render("✓ Tagged "+tag))
+
+	if opts.Push {
+		if err := repo.PushTag(tag); err != nil {
+			return exitError(ExitGit, "pushing tag: %v", err)
+		}
+		fmt.Fprintln(out, successStyle.Render(fmt.Sprintf("✓ Pushed %s to %s", tag, cfg.Remote)))
+	}
+
+	return nil
+}
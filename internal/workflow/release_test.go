@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"testing"
+
+	gitpkg "gcm/internal/git"
+	"gcm/internal/semver"
+)
+
+func tags(names ...string) []gitpkg.Tag {
+	var tags []gitpkg.Tag
+	for _, name := range names {
+		tags = append(tags, gitpkg.Tag{Name: name})
+	}
+	return tags
+}
+
+func commits(messages ...string) []gitpkg.Commit {
+	var commits []gitpkg.Commit
+	for _, message := range messages {
+		commits = append(commits, gitpkg.Commit{Message: message})
+	}
+	return commits
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []gitpkg.Tag
+		commits []gitpkg.Commit
+		pre     string
+		want    string
+		level   semver.Level
+	}{
+		{name: "nothing to release", tags: tags("v1.2.0"), commits: commits("docs: explain the flags", "chore: tidy"), want: "v1.2.0", level: semver.None},
+		{name: "no conventional commits", tags: tags("v1.2.0"), commits: commits("update things"), want: "v1.2.0", level: semver.None},
+		{name: "fix", tags: tags("v1.2.0"), commits: commits("docs: explain the flags", "fix: handle empty input"), want: "v1.2.1", level: semver.Patch},
+		{name: "perf", tags: tags("v1.2.0"), commits: commits("perf(git): batch status calls"), want: "v1.2.1", level: semver.Patch},
+		{name: "feature", tags: tags("v1.2.0"), commits: commits("fix: handle empty input", "feat(cli): add the version flag"), want: "v1.3.0", level: semver.Minor},
+		{name: "breaking mark", tags: tags("v1.2.0"), commits: commits("feat(cli)!: drop the -m flag", "fix: handle empty input"), want: "v2.0.0", level: semver.Major},
+		{name: "breaking footer", tags: tags("v1.2.0"), commits: commits("fix: handle empty input\n\nBREAKING CHANGE: empty input is now an error"), want: "v2.0.0", level: semver.Major},
+		{name: "ignored commits", tags: tags("v1.2.0"), commits: commits("Merge branch 'feat/x'", "fixup! feat: add x"), want: "v1.2.0", level: semver.None},
+		{name: "first release", commits: commits("feat: add the commit flow"), want: "v0.1.0", level: semver.Minor},
+		{name: "stable base", tags: tags("v1.2.0", "v1.3.0-rc.1", "latest", "v1.1.5"), commits: commits("fix: handle empty input"), want: "v1.2.1", level: semver.Patch},
+		{name: "highest stable base", tags: tags("v1.9.0", "v1.10.0"), commits: commits("fix: handle empty input"), want: "v1.10.1", level: semver.Patch},
+		{name: "first pre-release", tags: tags("v1.2.0"), commits: commits("feat: add x"), pre: "rc", want: "v1.3.0-rc.1", level: semver.Minor},
+		{name: "next pre-release", tags: tags("v1.2.0", "v1.3.0-rc.1", "v1.3.0-rc.2"), commits: commits("feat: add x"), pre: "rc", want: "v1.3.0-rc.3", level: semver.Minor},
+		{name: "pre-releases out of order", tags: tags("v1.3.0-rc.10", "v1.2.0", "v1.3.0-rc.9"), commits: commits("feat: add x"), pre: "rc", want: "v1.3.0-rc.11", level: semver.Minor},
+		{name: "another channel", tags: tags("v1.2.0", "v1.3.0-rc.2"), commits: commits("feat: add x"), pre: "beta", want: "v1.3.0-beta.1", level: semver.Minor},
+		{name: "pre-release of another version", tags: tags("v1.2.0", "v1.2.1-rc.4"), commits: commits("feat: add x"), pre: "rc", want: "v1.3.0-rc.1", level: semver.Minor},
+		{name: "pre-release without changes", tags: tags("v1.2.0"), commits: commits("docs: explain the flags"), pre: "rc", want: "v1.2.0", level: semver.None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, level := NextVersion(tt.tags, tt.commits, tt.pre)
+			if got.String() != tt.want || level != tt.level {
+				t.Errorf("NextVersion = %s, %s; want %s, %s", got, level, tt.want, tt.level)
+			}
+		})
+	}
+}
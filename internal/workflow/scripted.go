@@ -0,0 +1,266 @@
+package workflow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+)
+
+// Exit codes of a scripted run
+const (
+	ExitOK      = 0
+	ExitGit     = 1 // a git operation failed
+	ExitUsage   = 2 // missing or malformed flags
+	ExitInvalid = 3 // the message or branch name breaks a rule
+	ExitNothing = 4 // nothing to commit
+	ExitAborted = 5 // the confirmation was declined
+)
+
+// ExitError is a failed scripted run together with the process exit code
+// it maps to.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+func exitError(code int, format string, a ...any) error {
+	return &ExitError{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// ScriptedOptions describe a commit made without any TUI.
+type ScriptedOptions struct {
+	Type  string
+	Scope string
+	Title string
+	Body  string
+	Files []string // paths to add on top of the index; none commits the index as-is
+	Dir   string   // directory Files are relative to; the repository root if empty
+
+	Breaking       bool
+	BreakingChange string
+	Trailers       []string // "Token: value" footer lines
+	SignOff        bool     // add Signed-off-by with the configured identity
+
+	Branch string
+	Push   bool
+	Yes    bool // skip the confirmation prompt
+}
+
+// RunScripted validates opts with the same rules as the interactive flow,
+// then stages, commits and optionally pushes. Without Yes it asks for a y/n
+// answer on in before committing.
+func RunScripted(repo gitpkg.Repository, cfg config.Config, opts ScriptedOptions, in io.Reader, out io.Writer) error {
+	if opts.Type == "" || opts.Title == "" {
+		return exitError(ExitUsage, "--type and --title are required")
+	}
+
+	info := model.CommitInfo{
+		Type:        opts.Type,
+		Scope:       opts.Scope,
+		Title:       strings.TrimSpace(opts.Title),
+		Description: strings.TrimSpace(opts.Body),
+
+		Breaking:       opts.Breaking,
+		BreakingChange: strings.TrimSpace(opts.BreakingChange),
+	}
+
+	for _, line := range opts.Trailers {
+		t, ok := model.ParseTrailer(line)
+		if !ok {
+			return exitError(ExitUsage, "--trailer must look like 'Token: value', got %q", line)
+		}
+		if model.IsBreakingToken(t.Token) {
+			return exitError(ExitUsage, "use --breaking-change for the %s footer", t.Token)
+		}
+		info.Trailers = append(info.Trailers, t)
+	}
+
+	if opts.SignOff {
+		signOff, err := Identity(repo)
+		if err != nil {
+			return exitError(ExitGit, "reading committer identity: %v", err)
+		}
+		if signOff == "" {
+			return exitError(ExitInvalid, "--signoff needs user.name and user.email in git config")
+		}
+		info.Trailers = append(info.Trailers, model.Trailer{Token: model.TrailerSignedOff, Value: signOff})
+	}
+
+	if info.Scope != "" {
+		if err := lint.ValidateScope(info.Scope, nil); err != nil {
+			return exitError(ExitInvalid, "invalid scope: %v", err)
+		}
+	}
+
+	linter, err := NewLinter(repo, cfg)
+	if err != nil {
+		return exitError(ExitUsage, "%v", err)
+	}
+
+	violations := linter.Lint(info)
+	if lint.HasErrors(violations) {
+		return exitError(ExitInvalid, "invalid commit message: %s", lint.Summary(violations))
+	}
+	for _, v := range violations {
+		fmt.Fprintf(out, "⚠️  %s\n", v)
+	}
+
+	// Branch rules
+	currentBranch, err := repo.CurrentBranch()
+	if err != nil {
+		return exitError(ExitGit, "getting current branch: %v", err)
+	}
+
+	branchName := currentBranch
+	if opts.Branch != "" {
+		if err := lint.ValidateBranchName(opts.Branch, cfg.Branch); err != nil {
+			return exitError(ExitInvalid, "invalid branch name: %v", err)
+		}
+		branchName = opts.Branch
+	}
+
+	if gitpkg.IsProtectedBranch(branchName, cfg.ProtectedBranches) {
+		return exitError(ExitInvalid, "cannot commit directly to '%s', pass --branch", branchName)
+	}
+
+	// Work out what goes into the commit
+	status, err := repo.Status()
+	if err != nil {
+		return exitError(ExitGit, "checking changed files: %v", err)
+	}
+
+	changesList := changes.SplitStaged(status)
+	staged := changes.Staged(changesList)
+	selected := staged
+
+	for _, path := range opts.Files {
+		if opts.Dir != "" {
+			if path, err = repoPath(repo, opts.Dir, path); err != nil {
+				return exitError(ExitUsage, "%v", err)
+			}
+		}
+
+		matched := false
+		for _, it := range changesList {
+			switch {
+			case it.IsStaged():
+			case matchesPath(it.Path, path):
+				selected = append(selected, it)
+				matched = true
+			case it.Index == '?' && strings.HasSuffix(it.Path, "/") && strings.HasPrefix(path, it.Path):
+				// A path inside an untracked directory, which Status
+				// lists as a whole
+				it.Path = path
+				selected = append(selected, it)
+				matched = true
+			}
+		}
+		if !matched && !hasPath(staged, path) {
+			return exitError(ExitInvalid, "no changes in '%s'", path)
+		}
+	}
+
+	if len(selected) == 0 {
+		return exitError(ExitNothing, "nothing to commit: no staged changes and no --files given")
+	}
+
+	fmt.Fprintf(out, "Branch: %s\n", branchName)
+	fmt.Fprintf(out, "Files:  %d\n\n", len(selected))
+	fmt.Fprintf(out, "%s\n\n", info.FullMessage())
+
+	if !opts.Yes {
+		fmt.Fprint(out, "Commit? (y/n): ")
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return exitError(ExitAborted, "commit aborted")
+		}
+	}
+
+	if branchName != currentBranch {
+		if err := repo.CreateBranch(branchName); err != nil {
+			if err := repo.CheckoutBranch(branchName); err != nil {
+				return exitError(ExitGit, "switching to branch '%s': %v", branchName, err)
+			}
+		}
+	}
+
+	if err := stage(repo, staged, selected); err != nil {
+		return &ExitError{Code: ExitGit, Err: err}
+	}
+
+	if err := repo.Commit(info.FullMessage()); err != nil {
+		return exitError(ExitGit, "during git commit: %v", err)
+	}
+	fmt.Fprintf(out, "✓ Commit created: [%s] %s\n", info.Prefix(), info.Title)
+
+	if opts.Push {
+		hasRemote, _ := repo.HasRemoteBranch(branchName)
+		if err := repo.Push(branchName, !hasRemote); err != nil {
+			return exitError(ExitGit, "during push: %v", err)
+		}
+		fmt.Fprintf(out, "✓ Pushed to %s/%s\n", cfg.Remote, branchName)
+	}
+
+	return nil
+}
+
+// repoPath turns a path relative to dir into one relative to the top level
+// of the working tree, as Status reports them. Symlinked directories, such
+// as a $PWD through a link to the checkout, are resolved first, since git
+// reports the top level resolved.
+func repoPath(repo gitpkg.Repository, dir, path string) (string, error) {
+	root, err := repo.Root()
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	// The path itself may be deleted, or a tracked symlink to keep as is
+	path = filepath.Join(realDir(filepath.Dir(path)), filepath.Base(path))
+
+	rel, err := filepath.Rel(realDir(root), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("'%s' is outside the repository", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// realDir resolves the symlinks of an existing directory, returning any
+// other one as is.
+func realDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
+
+// matchesPath reports whether a changed path is the given path or lies
+// under it when it names a directory.
+func matchesPath(changed, path string) bool {
+	path = strings.TrimPrefix(path, "./")
+	if path == "." {
+		return true
+	}
+	return changed == path || strings.HasPrefix(changed, strings.TrimSuffix(path, "/")+"/")
+}
+
+func hasPath(items []model.GitChange, path string) bool {
+	for _, it := range items {
+		if matchesPath(it.Path, path) {
+			return true
+		}
+	}
+	return false
+}
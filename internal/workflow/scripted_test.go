@@ -0,0 +1,191 @@
+package workflow
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/model"
+)
+
+func scriptedRepo() *gitpkg.FakeRepository {
+	repo := gitpkg.NewFakeRepository("main",
+		model.GitChange{Index: 'M', Working: ' ', Path: "staged.go"},
+		model.GitChange{Index: ' ', Working: 'M', Path: "cmd/main.go"},
+		model.GitChange{Index: '?', Working: '?', Path: "docs/"},
+	)
+	repo.Dir = "/repo"
+	return repo
+}
+
+func TestRunScripted(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ScriptedOptions
+		in      string
+		code    int
+		branch  string
+		paths   []string
+		message string
+		pushed  []string
+	}{
+		{
+			name:    "index on a new branch",
+			opts:    ScriptedOptions{Type: "fix", Title: "handle empty input", Branch: "fix/12-input", Yes: true},
+			branch:  "fix/12-input",
+			paths:   []string{"staged.go"},
+			message: "fix: handle empty input",
+		},
+		{
+			name: "files, trailers and push",
+			opts: ScriptedOptions{
+				Type: "feat", Scope: "cli", Title: "add the version flag", Body: "Scripts ask for it.",
+				Files: []string{"cmd", "docs/intro.md"}, Trailers: []string{"Refs: #7"},
+				Branch: "feat/version", Push: true, Yes: true,
+			},
+			branch:  "feat/version",
+			paths:   []string{"staged.go", "cmd/main.go", "docs/intro.md"},
+			message: "feat(cli): add the version flag\n\nScripts ask for it.\n\nRefs: #7",
+			pushed:  []string{"feat/version"},
+		},
+		{
+			name:    "files relative to a subdirectory",
+			opts:    ScriptedOptions{Type: "fix", Title: "handle empty input", Files: []string{"main.go"}, Dir: "/repo/cmd", Branch: "fix/input", Yes: true},
+			branch:  "fix/input",
+			paths:   []string{"staged.go", "cmd/main.go"},
+			message: "fix: handle empty input",
+		},
+		{
+			name:    "confirmed on stdin",
+			opts:    ScriptedOptions{Type: "fix", Title: "handle empty input", Branch: "fix/input"},
+			in:      "yes\n",
+			branch:  "fix/input",
+			paths:   []string{"staged.go"},
+			message: "fix: handle empty input",
+		},
+		{name: "declined on stdin", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", Branch: "fix/input"}, in: "n\n", code: ExitAborted},
+		{name: "missing title", opts: ScriptedOptions{Type: "fix", Yes: true}, code: ExitUsage},
+		{name: "malformed trailer", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", Trailers: []string{"refs #7"}, Yes: true}, code: ExitUsage},
+		{name: "lint error", opts: ScriptedOptions{Type: "fix", Title: "Fix.", Branch: "fix/input", Yes: true}, code: ExitInvalid},
+		{name: "protected branch", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", Yes: true}, code: ExitInvalid},
+		{name: "unknown file", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", Files: []string{"nope.go"}, Branch: "fix/input", Yes: true}, code: ExitInvalid},
+		{name: "file outside the repository", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", Files: []string{"../x.go"}, Dir: "/repo", Branch: "fix/input", Yes: true}, code: ExitUsage},
+		{name: "signoff without identity", opts: ScriptedOptions{Type: "fix", Title: "handle empty input", SignOff: true, Branch: "fix/input", Yes: true}, code: ExitInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := scriptedRepo()
+			var out strings.Builder
+			err := RunScripted(repo, config.Default(), tt.opts, strings.NewReader(tt.in), &out)
+
+			if tt.code != ExitOK {
+				var exitErr *ExitError
+				if !errors.As(err, &exitErr) || exitErr.Code != tt.code {
+					t.Fatalf("err = %v, want exit code %d", err, tt.code)
+				}
+				if len(repo.Commits) != 0 || len(repo.Branches) != 1 {
+					t.Errorf("a failed run committed %+v on %v", repo.Commits, repo.Branches)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repo.Commits) != 1 {
+				t.Fatalf("commits = %+v, want one", repo.Commits)
+			}
+			c := repo.Commits[0]
+			if c.Branch != tt.branch || repo.Branch != tt.branch {
+				t.Errorf("committed on %s and left on %s, want %s", c.Branch, repo.Branch, tt.branch)
+			}
+			slices.Sort(c.Paths)
+			slices.Sort(tt.paths)
+			if !slices.Equal(c.Paths, tt.paths) {
+				t.Errorf("committed %v, want %v", c.Paths, tt.paths)
+			}
+			if c.Message != tt.message {
+				t.Errorf("message = %q, want %q", c.Message, tt.message)
+			}
+			if !slices.Equal(repo.Pushed, tt.pushed) {
+				t.Errorf("pushed %v, want %v", repo.Pushed, tt.pushed)
+			}
+		})
+	}
+}
+
+func TestRunScriptedExistingBranch(t *testing.T) {
+	repo := scriptedRepo()
+	repo.Branches = append(repo.Branches, "fix/input")
+	repo.RemoteBranches["fix/input"] = true
+
+	opts := ScriptedOptions{Type: "fix", Title: "handle empty input", SignOff: true, Branch: "fix/input", Push: true, Yes: true}
+	repo.Config["user.name"] = []string{"Ada Lovelace"}
+	repo.Config["user.email"] = []string{"ada@example.com"}
+	if err := RunScripted(repo, config.Default(), opts, strings.NewReader(""), &strings.Builder{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.Branch != "fix/input" || len(repo.Branches) != 2 {
+		t.Errorf("on %s of %v, want the existing fix/input checked out", repo.Branch, repo.Branches)
+	}
+	if want := "fix: handle empty input\n\nSigned-off-by: Ada Lovelace <ada@example.com>"; repo.Commits[0].Message != want {
+		t.Errorf("message = %q, want %q", repo.Commits[0].Message, want)
+	}
+	if !slices.Equal(repo.Pushed, []string{"fix/input"}) {
+		t.Errorf("pushed %v", repo.Pushed)
+	}
+}
+
+func TestRunScriptedNothing(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("fix/input", model.GitChange{Index: ' ', Working: 'M', Path: "a.go"})
+	err := RunScripted(repo, config.Default(), ScriptedOptions{Type: "fix", Title: "handle empty input", Yes: true}, strings.NewReader(""), &strings.Builder{})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitNothing {
+		t.Fatalf("err = %v, want exit code %d", err, ExitNothing)
+	}
+}
+
+func TestRunScriptedPushFails(t *testing.T) {
+	repo := scriptedRepo()
+	repo.Errors["Push"] = errors.New("remote hung up")
+
+	err := RunScripted(repo, config.Default(), ScriptedOptions{Type: "fix", Title: "handle empty input", Branch: "fix/input", Push: true, Yes: true}, strings.NewReader(""), &strings.Builder{})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitGit {
+		t.Fatalf("err = %v, want exit code %d", err, ExitGit)
+	}
+	if len(repo.Commits) != 1 {
+		t.Errorf("commits = %+v, want the commit kept", repo.Commits)
+	}
+}
+
+func TestRunScriptedSymlinkedDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "cmd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(root, link); err != nil {
+		t.Skip("no symlinks:", err)
+	}
+
+	repo := scriptedRepo()
+	repo.Dir = root
+	opts := ScriptedOptions{Type: "fix", Title: "handle empty input", Files: []string{"main.go"}, Dir: filepath.Join(link, "cmd"), Branch: "fix/input", Yes: true}
+	if err := RunScripted(repo, config.Default(), opts, strings.NewReader(""), &strings.Builder{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := repo.Commits[0].Paths; !slices.Contains(paths, "cmd/main.go") {
+		t.Errorf("committed %v, want cmd/main.go", paths)
+	}
+}
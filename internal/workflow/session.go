@@ -0,0 +1,686 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	"gcm/internal/draft"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/lint"
+	"gcm/internal/model"
+	"gcm/internal/ui"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46")).
+			Bold(true)
+
+	infoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("86"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("226"))
+)
+
+// ScopeConfigKey is the multi-valued git config key listing the scopes a
+// repository allows, e.g. `git config --add gcm.scope auth`. It adds to the
+// scopes of the configuration file.
+const ScopeConfigKey = "gcm.scope"
+
+// scopeCatalogue returns the allowed scopes from the configuration file and
+// git config; empty means any scope is allowed.
+func scopeCatalogue(repo gitpkg.Repository, cfg config.Config) ([]string, error) {
+	scopes, err := repo.ConfigValues(ScopeConfigKey)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := slices.Clone(cfg.Scopes)
+	for _, scope := range scopes {
+		if !slices.Contains(catalogue, scope) {
+			catalogue = append(catalogue, scope)
+		}
+	}
+	return catalogue, nil
+}
+
+// NewLinter returns the commit message linter for cfg and the scope
+// catalogue of repo.
+func NewLinter(repo gitpkg.Repository, cfg config.Config) (*lint.Engine, error) {
+	catalogue, err := scopeCatalogue(repo, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("reading scope catalogue: %w", err)
+	}
+	return lint.New(cfg, catalogue)
+}
+
+const (
+	// groupMinChanges is how many changes it takes to propose groups
+	groupMinChanges = 6
+	// groupHistory is how many recent commits grouping learns from
+	groupHistory = 200
+)
+
+// Session is one interactive gcm run: pick a branch, then commit groups of
+// changes until the tree is clean or the user stops, and offer to push.
+// Until the first commit the user can step back all the way to the branch
+// choice, so a new branch is only created then.
+type Session struct {
+	Repo   gitpkg.Repository
+	UI     Prompter
+	Out    io.Writer
+	Config config.Config
+
+	// Drafter pre-fills commit messages; nil leaves them blank
+	Drafter *draft.Client
+
+	// Commits created so far, as "[type] title"
+	Commits []string
+
+	branch  string // chosen branch
+	current string // checked out branch
+
+	// Accepted groups not committed yet, out of planTotal
+	plan      []changes.Group
+	planTotal int
+}
+
+// roundEnd tells the commit loop how a round ended.
+type roundEnd int
+
+const (
+	roundNext roundEnd = iota // go on with another round
+	roundStop                 // end the loop
+	roundBack                 // stepped back before the first commit
+)
+
+// Steps of a commit round, in order
+const (
+	stepFiles = iota
+	stepType
+	stepCustomType
+	stepScope
+	stepMessage
+	stepCommit
+)
+
+// round holds what the user entered in a commit round, so it survives
+// stepping back and forth.
+type round struct {
+	planned    *changes.Group
+	revisit    bool // back at the files step of a planned group
+	selected   []model.GitChange
+	commitType string
+	scope      string
+	info       model.CommitInfo
+	drafted    bool
+}
+
+func (s *Session) println(a ...any) {
+	fmt.Fprintln(s.Out, a...)
+}
+
+func (s *Session) printf(format string, a ...any) {
+	fmt.Fprintf(s.Out, format, a...)
+}
+
+// Run executes the session. Errors before the first commit attempt are
+// returned; later ones are reported and end the commit loop.
+func (s *Session) Run() error {
+	// Step 1: Check for changes
+	changesList, err := s.Repo.Status()
+	if err != nil {
+		return fmt.Errorf("checking changed files: %w", err)
+	}
+
+	if len(changesList) == 0 {
+		s.println(successStyle.Render("✨ Working tree clean, nothing to commit"))
+		return nil
+	}
+
+	currentBranch, err := s.Repo.CurrentBranch()
+	if err != nil {
+		return fmt.Errorf("getting current branch: %w", err)
+	}
+	s.current = currentBranch
+
+	isMainBranch := gitpkg.IsProtectedBranch(currentBranch, s.Config.ProtectedBranches)
+
+	for {
+		// Step 2: Branch management
+		branchName, confirmed, err := s.UI.SelectBranch(currentBranch, s.branch, isMainBranch)
+		if errors.Is(err, ui.ErrBack) {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("in branch selection: %w", err)
+		}
+
+		if !confirmed {
+			s.println("Canceled.")
+			return nil
+		}
+
+		s.branch = branchName
+		if branchName == currentBranch {
+			s.println(infoStyle.Render(fmt.Sprintf("📌 Using branch '%s'", branchName)))
+		} else {
+			s.println(infoStyle.Render(fmt.Sprintf("🌱 Branch '%s' will be created with the first commit", branchName)))
+		}
+
+		// Step 3: Offer to split many changes into several commits
+		switch s.proposeGroups() {
+		case roundBack:
+			continue
+		case roundStop:
+			s.println("Canceled.")
+			return nil
+		}
+
+		// Step 4: Loop until no more changes or user quits
+		end := s.commitOnce()
+		for end == roundNext {
+			end = s.commitOnce()
+		}
+		if end != roundBack {
+			break
+		}
+	}
+
+	s.offerPush(s.branch)
+
+	s.println("\n" + successStyle.Render("✨ Done."))
+	return nil
+}
+
+// commitOnce runs one selection/message/commit round, in which Esc goes
+// back a step, and reports how it ended.
+func (s *Session) commitOnce() roundEnd {
+	// Re-check for changes
+	status, err := s.Repo.Status()
+	if err != nil {
+		s.println("❌ Error checking changed files:", err)
+		return roundStop
+	}
+
+	changesList := changes.SplitStaged(status)
+
+	if len(changesList) == 0 {
+		s.println("\n" + successStyle.Render("✨ All files committed!"))
+		return roundStop
+	}
+
+	// Line counts for the selector, which does without them
+	if stats, err := s.Repo.DiffStats(changesList); err == nil {
+		for i := range changesList {
+			changesList[i].Stat = stats[i]
+		}
+	}
+
+	s.printf("\n%s\n", infoStyle.Render(fmt.Sprintf("📋 %d file(s) with changes", len(changesList))))
+
+	staged := changes.Staged(changesList)
+
+	var r round
+	if len(s.plan) > 0 {
+		r.planned = &s.plan[0]
+		s.plan = s.plan[1:]
+	}
+
+	catalogue, err := scopeCatalogue(s.Repo, s.Config)
+	if err != nil {
+		s.println("❌ Error reading scope catalogue:", err)
+		return roundStop
+	}
+
+	for step := stepFiles; step != stepCommit; {
+		switch step {
+		case stepFiles:
+			// Step 5: The planned group, file selection, or the index
+			// as-is if the user pre-staged
+			selected, err := s.selectFiles(&r, changesList, staged)
+			if errors.Is(err, ui.ErrBack) {
+				if len(s.Commits) == 0 {
+					return roundBack
+				}
+				s.println("No files selected, exiting.")
+				return roundStop
+			}
+			if err != nil {
+				s.println("❌ Error running UI:", err)
+				return roundStop
+			}
+
+			if err := checkHalves(staged, selected); err != nil {
+				// Back to the selector with the pick kept
+				s.println("❌", err)
+				r.selected, r.revisit = selected, true
+				continue
+			}
+
+			if len(selected) == 0 {
+				if r.planned != nil && !r.revisit {
+					s.println("The group's changes are gone, skipping it.")
+					if len(s.plan) > 0 {
+						return roundNext
+					}
+					return roundStop
+				}
+				s.println("No files selected, exiting.")
+				return roundStop
+			}
+			r.selected = selected
+
+			// Show warning for large commits
+			if len(selected) > 10 {
+				s.println(warningStyle.Render(fmt.Sprintf("⚠️  Warning: Large commit with %d files", len(selected))))
+			}
+			step = stepType
+
+		case stepType:
+			// Step 6: Commit type selection, starting on the earlier
+			// choice or the type the group or the changes suggest
+			suggested, reason := changes.SuggestType(r.selected, s.Config.TypeRules, s.diffOf)
+			if r.planned != nil && r.planned.Type != "" {
+				suggested, reason = r.planned.Type, r.planned.Reason
+			}
+			if r.commitType != "" {
+				suggested, reason = r.commitType, "your earlier choice"
+			}
+
+			commitType, isCustom, err := s.UI.SelectCommitType(suggested, reason)
+			if errors.Is(err, ui.ErrBack) {
+				r.revisit = true
+				step = stepFiles
+				continue
+			}
+			if err != nil {
+				s.println("❌ Error selecting commit type:", err)
+				return roundStop
+			}
+
+			if commitType == "" && !isCustom {
+				s.println("No commit type selected, exiting.")
+				return roundStop
+			}
+
+			step = stepScope
+			if isCustom {
+				step = stepCustomType
+			} else {
+				r.commitType = commitType
+			}
+
+		case stepCustomType:
+			customType, ok, err := s.UI.Input("Enter custom commit type:")
+			if errors.Is(err, ui.ErrBack) {
+				step = stepType
+				continue
+			}
+			if err != nil {
+				s.println("❌ Error getting custom type:", err)
+				return roundStop
+			}
+			if !ok || customType == "" {
+				s.println("No custom type provided, exiting.")
+				return roundStop
+			}
+			r.commitType = customType
+			step = stepScope
+
+		case stepScope:
+			// Step 7: Scope, suggested from the group or the selected paths
+			scopes := changes.SuggestScopes(r.selected)
+			if r.planned != nil && r.planned.Scope != "" {
+				planned := r.planned.Scope
+				scopes = append([]string{planned}, slices.DeleteFunc(scopes, func(sc string) bool { return sc == planned })...)
+			}
+
+			scope, ok, err := s.UI.SelectScope(r.commitType, r.scope, scopes, catalogue)
+			if errors.Is(err, ui.ErrBack) {
+				r.scope = scope
+				step = stepType
+				continue
+			}
+			if err != nil {
+				s.println("❌ Error selecting scope:", err)
+				return roundStop
+			}
+			if !ok {
+				s.println("No scope confirmed, exiting.")
+				return roundStop
+			}
+			r.scope = scope
+			step = stepMessage
+
+		case stepMessage:
+			// Step 8: Commit message, drafted on the first visit if
+			// configured
+			info := r.info
+			info.Type, info.Scope = r.commitType, r.scope
+			if !r.drafted {
+				info = s.draft(info, r.selected)
+				r.drafted = true
+			}
+
+			info, confirmed, err := s.UI.CommitMessage(info, s.trailerSuggestions())
+			if errors.Is(err, ui.ErrBack) {
+				r.info = info
+				step = stepScope
+				continue
+			}
+			if err != nil {
+				s.println("❌ Error getting commit message:", err)
+				return roundStop
+			}
+
+			if !confirmed {
+				s.println("Commit message not confirmed, exiting.")
+				return roundStop
+			}
+			r.info = info
+			step = stepCommit
+		}
+	}
+
+	if err := s.checkout(); err != nil {
+		s.println("❌ Error", err)
+		return roundStop
+	}
+
+	// Step 9: Stage files
+	if err := stage(s.Repo, staged, r.selected); err != nil {
+		s.println("❌ Error", err)
+		return roundStop
+	}
+
+	// Step 10: Commit
+	info := r.info
+	if err := s.Repo.Commit(info.FullMessage()); err != nil {
+		s.println("❌ Error during git commit:", err)
+		return roundStop
+	}
+
+	s.println(successStyle.Render(fmt.Sprintf("✓ Commit created: [%s] %s", info.Prefix(), info.Title)))
+	s.Commits = append(s.Commits, fmt.Sprintf("[%s] %s", info.Prefix(), info.Title))
+
+	// Step 11: Check if there are more uncommitted files
+	remainingChanges, err := s.Repo.Status()
+	if err != nil {
+		return roundStop
+	}
+
+	if len(remainingChanges) == 0 {
+		s.println("\n" + successStyle.Render("✨ All files committed!"))
+		return roundStop
+	}
+
+	// Ask if user wants to continue
+	s.printf("\n%s\n", infoStyle.Render("Current status:"))
+	s.printf("  - %d file(s) committed\n", len(r.selected))
+	s.printf("  - %d file(s) still uncommitted\n\n", len(remainingChanges))
+
+	// Accepted groups are committed without asking again
+	if len(s.plan) > 0 {
+		return roundNext
+	}
+
+	continueCommit, err := s.UI.Confirm("Want to create another commit on the same branch?")
+	if err != nil || !continueCommit {
+		return roundStop
+	}
+	return roundNext
+}
+
+// selectFiles returns the planned group's changes on the first visit, the
+// index as-is if the user pre-staged and says so, or the user's pick.
+func (s *Session) selectFiles(r *round, changesList, staged []model.GitChange) ([]model.GitChange, error) {
+	if r.planned != nil && !r.revisit {
+		selected := inPaths(changesList, r.planned.Paths())
+		s.println(infoStyle.Render(fmt.Sprintf("🧩 Group %d of %d: %d file(s)", s.planTotal-len(s.plan), s.planTotal, len(selected))))
+		return selected, nil
+	}
+
+	if len(staged) > 0 && r.selected == nil {
+		s.println(infoStyle.Render(fmt.Sprintf("📥 %d change(s) already staged", len(staged))))
+		indexOnly, err := s.UI.Confirm("Commit exactly the staged changes?")
+		if err != nil {
+			return nil, err
+		}
+		if indexOnly {
+			return staged, nil
+		}
+	}
+
+	return s.UI.SelectFiles(changesList, r.selected, s.Repo.Diff)
+}
+
+// checkout creates the chosen branch before the first commit on it.
+func (s *Session) checkout() error {
+	if s.branch == s.current {
+		return nil
+	}
+
+	if err := s.Repo.CreateBranch(s.branch); err != nil {
+		return fmt.Errorf("creating branch: %w", err)
+	}
+	s.current = s.branch
+	s.println(successStyle.Render(fmt.Sprintf("✓ Created and switched to branch '%s'", s.branch)))
+	return nil
+}
+
+// proposeGroups clusters many changes into proposed commits and, once the
+// user accepts them, plans to commit them one by one. It returns roundBack
+// if the user stepped back and roundStop if they canceled; other failures
+// only cost the proposal.
+func (s *Session) proposeGroups() roundEnd {
+	s.plan, s.planTotal = nil, 0
+
+	status, err := s.Repo.Status()
+	if err != nil {
+		return roundNext
+	}
+
+	changesList := changes.SplitStaged(status)
+	if len(changesList) < groupMinChanges {
+		return roundNext
+	}
+
+	history, _ := s.Repo.ChangedPaths(groupHistory)
+	groups := changes.GroupChanges(changesList, history, s.Config.TypeRules, s.diffOf)
+	if len(groups) < 2 {
+		return roundNext
+	}
+
+	accepted, ok, err := s.UI.ReviewGroups(groups)
+	if errors.Is(err, ui.ErrBack) {
+		return roundBack
+	}
+	if err != nil {
+		s.println("❌ Error running UI:", err)
+		return roundNext
+	}
+	if !ok {
+		return roundStop
+	}
+	s.plan = accepted
+	s.planTotal = len(accepted)
+	return roundNext
+}
+
+// draft asks the Drafter for a title and description. Failures are
+// reported and leave the message blank.
+func (s *Session) draft(info model.CommitInfo, selected []model.GitChange) model.CommitInfo {
+	if s.Drafter == nil {
+		return info
+	}
+
+	diff, err := draft.Diff(selected, s.diffOf, s.Config.Draft.Ignore, s.Config.Draft.MaxDiffBytes)
+	if err != nil {
+		s.println(warningStyle.Render(fmt.Sprintf("⚠️  No draft: %v", err)))
+		return info
+	}
+
+	s.println(infoStyle.Render(fmt.Sprintf("✍️  Drafting the message with %s...", s.Config.Draft.Model)))
+	drafted, err := s.Drafter.Draft(context.Background(), info, diff)
+	if err != nil {
+		s.println(warningStyle.Render(fmt.Sprintf("⚠️  No draft: %v", err)))
+		return info
+	}
+	return drafted
+}
+
+// diffOf returns the diff a change would contribute to the next commit.
+func (s *Session) diffOf(change model.GitChange) (string, error) {
+	return s.Repo.Diff(change, change.IsStaged())
+}
+
+// inPaths returns the changes to any of paths, staged or not.
+func inPaths(items []model.GitChange, paths []string) []model.GitChange {
+	var res []model.GitChange
+	for _, it := range items {
+		if slices.Contains(paths, it.Path) {
+			res = append(res, it)
+		}
+	}
+	return res
+}
+
+// trailerSuggestions gathers co-authors from the history, issue refs from
+// the branch name and the committer identity for Signed-off-by. Failures
+// only cost suggestions.
+func (s *Session) trailerSuggestions() ui.TrailerSuggestions {
+	authors, _ := s.Repo.Authors()
+	signOff, _ := Identity(s.Repo)
+
+	// The committer doesn't co-author their own commit
+	authors = slices.DeleteFunc(authors, func(a string) bool { return a == signOff })
+
+	return ui.TrailerSuggestions{
+		CoAuthors: authors,
+		IssueRefs: model.IssueRefsFromBranch(s.branch, s.Config.Types),
+		SignOff:   signOff,
+	}
+}
+
+// Identity returns the configured committer as "Name <email>", or "" if
+// user.name or user.email is unset.
+func Identity(repo gitpkg.Repository) (string, error) {
+	names, err := repo.ConfigValues("user.name")
+	if err != nil {
+		return "", err
+	}
+	emails, err := repo.ConfigValues("user.email")
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 || len(emails) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%s <%s>", names[len(names)-1], emails[len(emails)-1]), nil
+}
+
+// checkHalves refuses a selection that keeps the unstaged edits of a file
+// but drops its staged ones. The unstaged edits are a diff against the
+// index, so they can't be committed without what is staged.
+func checkHalves(staged, selected []model.GitChange) error {
+	for _, it := range staged {
+		if slices.Contains(selected, it) {
+			continue
+		}
+		for _, other := range selected {
+			if !other.IsStaged() && other.Path == it.Path {
+				return fmt.Errorf("the unstaged changes of '%s' build on its staged ones: select both or only the staged ones", it.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// stage brings the index in line with the selection: deselected staged
+// entries are taken out of it, partial selections are applied hunk by hunk
+// and everything else is added whole.
+func stage(repo gitpkg.Repository, staged, selected []model.GitChange) error {
+	if err := checkHalves(staged, selected); err != nil {
+		return err
+	}
+
+	var unstage []string
+	for _, it := range staged {
+		if !slices.Contains(selected, it) {
+			unstage = append(unstage, it.Path)
+			if it.OrigPath != "" {
+				unstage = append(unstage, it.OrigPath)
+			}
+		}
+	}
+
+	if err := repo.Unstage(unstage); err != nil {
+		return fmt.Errorf("unstaging deselected files: %w", err)
+	}
+
+	var paths []string
+	var patches []string
+	for _, it := range selected {
+		switch {
+		case it.IsStaged():
+		case it.Patch != "":
+			patches = append(patches, it.Patch)
+		default:
+			paths = append(paths, it.Path)
+		}
+	}
+
+	if err := repo.Add(paths); err != nil {
+		return fmt.Errorf("during git add: %w", err)
+	}
+
+	for _, patch := range patches {
+		if err := repo.ApplyCached(patch); err != nil {
+			return fmt.Errorf("applying selected hunks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Step 12: Show summary and offer push
+func (s *Session) offerPush(branchName string) {
+	if len(s.Commits) == 0 {
+		return
+	}
+
+	s.println("\n" + strings.Repeat("─", 50))
+	s.println(successStyle.Render("📦 Commits created in this session:"))
+	for i, commit := range s.Commits {
+		s.printf("  %d. %s\n", i+1, commit)
+	}
+	s.println(strings.Repeat("─", 50))
+
+	// Offer to push
+	shouldPush, err := s.UI.Confirm("\nPush to remote?")
+	if err != nil || !shouldPush {
+		s.println("\n" + infoStyle.Render(fmt.Sprintf("💡 You can push later with: git push %s %s", s.Config.Remote, branchName)))
+		return
+	}
+
+	// Check if remote branch exists
+	hasRemote, _ := s.Repo.HasRemoteBranch(branchName)
+
+	s.printf("\n🚀 Pushing to %s/%s...\n", s.Config.Remote, branchName)
+
+	err = s.Repo.Push(branchName, !hasRemote)
+	if err != nil {
+		s.println("❌ Error during push:", err)
+	} else {
+		s.println(successStyle.Render("✓ Successfully pushed to remote"))
+	}
+}
@@ -0,0 +1,326 @@
+package workflow
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"gcm/internal/changes"
+	"gcm/internal/config"
+	gitpkg "gcm/internal/git"
+	"gcm/internal/model"
+	"gcm/internal/ui"
+)
+
+// answer is what the stub prompter replies to one prompt.
+type answer struct {
+	method string
+	value  any // string, the paths to select, or the commit title
+	ok     bool
+	err    error
+}
+
+// call is one prompt the session showed, with its arguments.
+type call struct {
+	method string
+	args   []any
+}
+
+// stubPrompter replies to the session's prompts with answers in order and
+// records them. A prompt out of order fails the test.
+type stubPrompter struct {
+	t       *testing.T
+	answers []answer
+	calls   []call
+}
+
+func (p *stubPrompter) next(method string, args ...any) answer {
+	p.t.Helper()
+	p.calls = append(p.calls, call{method, args})
+	if len(p.answers) == 0 {
+		p.t.Fatalf("unexpected %s%v after the last answer", method, args)
+	}
+	a := p.answers[0]
+	if a.method != method {
+		p.t.Fatalf("prompted %s%v, want %s", method, args, a.method)
+	}
+	p.answers = p.answers[1:]
+	return a
+}
+
+func (p *stubPrompter) SelectBranch(currentBranch, chosen string, isMainBranch bool) (string, bool, error) {
+	a := p.next("SelectBranch", currentBranch, chosen, isMainBranch)
+	branch, _ := a.value.(string)
+	return branch, a.ok, a.err
+}
+
+func (p *stubPrompter) SelectFiles(items, selected []model.GitChange, loadDiff ui.DiffFunc) ([]model.GitChange, error) {
+	a := p.next("SelectFiles", paths(items), paths(selected))
+	if a.err != nil {
+		return nil, a.err
+	}
+	return inPaths(items, a.value.([]string)), nil
+}
+
+func (p *stubPrompter) ReviewGroups(groups []changes.Group) ([]changes.Group, bool, error) {
+	a := p.next("ReviewGroups", len(groups))
+	return groups, a.ok, a.err
+}
+
+func (p *stubPrompter) SelectCommitType(suggested, reason string) (string, bool, error) {
+	a := p.next("SelectCommitType", suggested)
+	commitType, _ := a.value.(string)
+	return commitType, a.ok, a.err
+}
+
+func (p *stubPrompter) Input(prompt string) (string, bool, error) {
+	a := p.next("Input", prompt)
+	value, _ := a.value.(string)
+	return value, a.ok, a.err
+}
+
+func (p *stubPrompter) SelectScope(commitType, current string, suggestions, catalogue []string) (string, bool, error) {
+	a := p.next("SelectScope", commitType, current)
+	scope, _ := a.value.(string)
+	return scope, a.ok, a.err
+}
+
+func (p *stubPrompter) CommitMessage(info model.CommitInfo, suggestions ui.TrailerSuggestions) (model.CommitInfo, bool, error) {
+	a := p.next("CommitMessage", info.Header(), suggestions.IssueRefs)
+	info.Title, _ = a.value.(string)
+	return info, a.ok, a.err
+}
+
+func (p *stubPrompter) Confirm(prompt string) (bool, error) {
+	a := p.next("Confirm", prompt)
+	return a.ok, a.err
+}
+
+func (p *stubPrompter) ConfirmRelease(current, next, reason string, commits []string) (bool, error) {
+	a := p.next("ConfirmRelease", current, next)
+	return a.ok, a.err
+}
+
+func paths(items []model.GitChange) []string {
+	var res []string
+	for _, it := range items {
+		res = append(res, it.Path)
+	}
+	return res
+}
+
+// run drives a session over repo with answers, checking that every one
+// was used.
+func run(t *testing.T, repo *gitpkg.FakeRepository, answers ...answer) (*Session, *stubPrompter) {
+	t.Helper()
+	prompter := &stubPrompter{t: t, answers: answers}
+	s := &Session{Repo: repo, UI: prompter, Out: io.Discard, Config: config.Default()}
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompter.answers) > 0 {
+		t.Fatalf("the session ended with answers left: %+v", prompter.answers)
+	}
+	return s, prompter
+}
+
+// prompt returns the arguments of the i-th prompt of method.
+func (p *stubPrompter) prompt(method string, i int) []any {
+	for _, c := range p.calls {
+		if c.method == method {
+			if i == 0 {
+				return c.args
+			}
+			i--
+		}
+	}
+	p.t.Fatalf("no %s prompt #%d", method, i)
+	return nil
+}
+
+func TestSessionNewBranch(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("main",
+		model.GitChange{Index: ' ', Working: 'M', Path: "auth/login.go"},
+		model.GitChange{Index: '?', Working: '?', Path: "auth/login_test.go"},
+	)
+
+	s, p := run(t, repo,
+		answer{method: "SelectBranch", value: "feat/12-login", ok: true},
+		answer{method: "SelectFiles", value: []string{"auth/login.go", "auth/login_test.go"}},
+		answer{method: "SelectCommitType", value: "feat"},
+		answer{method: "SelectScope", value: "auth", ok: true},
+		answer{method: "CommitMessage", value: "add the login form", ok: true},
+		answer{method: "Confirm", ok: true}, // push
+	)
+
+	if args := p.prompt("SelectBranch", 0); args[0] != "main" || args[2] != true {
+		t.Errorf("SelectBranch%v, want main marked as protected", args)
+	}
+	if args := p.prompt("CommitMessage", 0); args[0] != "feat(auth): " || !slices.Equal(args[1].([]string), []string{"#12"}) {
+		t.Errorf("CommitMessage%v, want the feat(auth) header and #12 suggested", args)
+	}
+
+	if repo.Branch != "feat/12-login" || !slices.Equal(repo.Branches, []string{"main", "feat/12-login"}) {
+		t.Errorf("on %s of %v, want the new feat/12-login", repo.Branch, repo.Branches)
+	}
+	want := gitpkg.FakeCommit{
+		Branch:  "feat/12-login",
+		Message: "feat(auth): add the login form",
+		Paths:   []string{"auth/login.go", "auth/login_test.go"},
+	}
+	if len(repo.Commits) != 1 || repo.Commits[0].Message != want.Message ||
+		repo.Commits[0].Branch != want.Branch || !slices.Equal(repo.Commits[0].Paths, want.Paths) {
+		t.Errorf("commits = %+v, want %+v", repo.Commits, want)
+	}
+	if !slices.Equal(s.Commits, []string{"[feat(auth)] add the login form"}) {
+		t.Errorf("session commits = %v", s.Commits)
+	}
+	if !slices.Equal(repo.Pushed, []string{"feat/12-login"}) || !repo.RemoteBranches["feat/12-login"] {
+		t.Errorf("pushed %v, want feat/12-login with an upstream", repo.Pushed)
+	}
+}
+
+func TestSessionStaged(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexOnly bool
+		answers   []answer
+		commits   [][]string
+		left      []model.GitChange
+	}{
+		{
+			name:      "commit the index, then the rest",
+			indexOnly: true,
+			answers: []answer{
+				{method: "SelectCommitType", value: "fix"},
+				{method: "SelectScope", ok: true},
+				{method: "CommitMessage", value: "handle empty input", ok: true},
+				{method: "Confirm", ok: true}, // another commit
+				{method: "SelectFiles", value: []string{"b.go"}},
+				{method: "SelectCommitType", value: "docs"},
+				{method: "SelectScope", ok: true},
+				{method: "CommitMessage", value: "explain the input rules", ok: true},
+			},
+			commits: [][]string{{"a.go"}, {"b.go"}},
+		},
+		{
+			name: "deselect the staged file",
+			answers: []answer{
+				{method: "SelectFiles", value: []string{"b.go"}},
+				{method: "SelectCommitType", value: "docs"},
+				{method: "SelectScope", ok: true},
+				{method: "CommitMessage", value: "explain the input rules", ok: true},
+				{method: "Confirm"}, // another commit
+			},
+			commits: [][]string{{"b.go"}},
+			left:    []model.GitChange{{Index: ' ', Working: 'M', Path: "a.go"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := gitpkg.NewFakeRepository("fix/input",
+				model.GitChange{Index: 'M', Working: ' ', Path: "a.go"},
+				model.GitChange{Index: ' ', Working: 'M', Path: "b.go"},
+			)
+			repo.RemoteBranches["fix/input"] = true
+
+			answers := []answer{
+				{method: "SelectBranch", value: "fix/input", ok: true},
+				{method: "Confirm", ok: tt.indexOnly}, // exactly the staged changes
+			}
+			answers = append(answers, tt.answers...)
+			answers = append(answers, answer{method: "Confirm", ok: true}) // push
+			run(t, repo, answers...)
+
+			var commits [][]string
+			for _, c := range repo.Commits {
+				commits = append(commits, c.Paths)
+			}
+			if !slices.EqualFunc(commits, tt.commits, slices.Equal) {
+				t.Errorf("committed %v, want %v", commits, tt.commits)
+			}
+			if !slices.Equal(repo.Changes, tt.left) {
+				t.Errorf("left %+v, want %+v", repo.Changes, tt.left)
+			}
+			// The branch exists on the remote, so no upstream is set
+			if !slices.Equal(repo.Pushed, []string{"fix/input"}) {
+				t.Errorf("pushed %v", repo.Pushed)
+			}
+		})
+	}
+}
+
+func TestSessionBack(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("main",
+		model.GitChange{Index: ' ', Working: 'M', Path: "a.go"},
+		model.GitChange{Index: ' ', Working: 'M', Path: "b.go"},
+	)
+
+	_, p := run(t, repo,
+		answer{method: "SelectBranch", value: "fix/a", ok: true},
+		answer{method: "SelectFiles", value: []string{"a.go"}},
+		answer{method: "SelectCommitType", value: "fix"},
+		answer{method: "SelectScope", value: "core", err: ui.ErrBack},
+		answer{method: "SelectCommitType", err: ui.ErrBack},
+		answer{method: "SelectFiles", err: ui.ErrBack},
+		// Back at the branch before any commit: nothing was created
+		answer{method: "SelectBranch", value: "fix/b", ok: true},
+		answer{method: "SelectFiles", value: []string{"b.go"}},
+		answer{method: "SelectCommitType", value: "fix"},
+		answer{method: "SelectScope", value: "core", ok: true},
+		answer{method: "CommitMessage", value: "handle the b case", ok: true},
+		answer{method: "Confirm"}, // another commit
+		answer{method: "Confirm"}, // push
+	)
+
+	// Each step comes back with the earlier answer
+	if args := p.prompt("SelectCommitType", 1); args[0] != "fix" {
+		t.Errorf("SelectCommitType%v after going back, want fix suggested", args)
+	}
+	if args := p.prompt("SelectFiles", 1); !slices.Equal(args[1].([]string), []string{"a.go"}) {
+		t.Errorf("SelectFiles%v after going back, want a.go kept", args)
+	}
+	if args := p.prompt("SelectBranch", 1); args[1] != "fix/a" {
+		t.Errorf("SelectBranch%v after going back, want fix/a kept", args)
+	}
+
+	if !slices.Equal(repo.Branches, []string{"main", "fix/b"}) {
+		t.Errorf("branches = %v, want only fix/b created", repo.Branches)
+	}
+	if len(repo.Commits) != 1 || repo.Commits[0].Branch != "fix/b" || !slices.Equal(repo.Commits[0].Paths, []string{"b.go"}) {
+		t.Errorf("commits = %+v, want b.go on fix/b", repo.Commits)
+	}
+	if len(repo.Pushed) != 0 {
+		t.Errorf("pushed %v after declining", repo.Pushed)
+	}
+}
+
+func TestSessionCanceled(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("feat/x", model.GitChange{Index: ' ', Working: 'M', Path: "a.go"})
+
+	run(t, repo,
+		answer{method: "SelectBranch", value: "feat/x", ok: true},
+		answer{method: "SelectFiles", value: []string{"a.go"}},
+		answer{method: "SelectCommitType", value: "feat"},
+		answer{method: "SelectScope", ok: true},
+		answer{method: "CommitMessage", value: "add the x feature"}, // not confirmed
+	)
+
+	if len(repo.Commits) != 0 || repo.Changes[0].Index != ' ' {
+		t.Errorf("a canceled session committed %+v or staged %+v", repo.Commits, repo.Changes)
+	}
+}
+
+func TestSessionCleanTree(t *testing.T) {
+	repo := gitpkg.NewFakeRepository("main")
+	var out strings.Builder
+	s := &Session{Repo: repo, UI: &stubPrompter{t: t}, Out: &out, Config: config.Default()}
+	if err := s.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "nothing to commit") {
+		t.Errorf("output = %q, want nothing to commit", out.String())
+	}
+}
@@ -1,229 +1,276 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
-	"gcm/internal/changes"
+	"gcm/internal/config"
+	"gcm/internal/draft"
 	gitpkg "gcm/internal/git"
 	"gcm/internal/ui"
-	"github.com/charmbracelet/lipgloss"
-)
-
-var (
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("46")).
-			Bold(true)
-
-	infoStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("86"))
-
-	warningStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("226"))
+	"gcm/internal/workflow"
 )
 
 func main() {
-	// Step 1: Check for changes
-	output, err := gitpkg.CheckChangedFiles()
-	if err != nil {
-		fmt.Println("❌ Error checking changed files:", err)
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			lintMain(os.Args[2:])
+			return
+		case "hook":
+			hookMain(os.Args[2:])
+			return
+		case "changelog":
+			changelogMain(os.Args[2:])
+			return
+		case "release":
+			releaseMain(os.Args[2:])
+			return
+		}
 	}
 
-	changesList := changes.ParseChangedFiles(output)
-
-	if len(changesList) == 0 {
-		fmt.Println(successStyle.Render("✨ Working tree clean, nothing to commit"))
+	var opts workflow.ScriptedOptions
+
+	backend := flag.String("backend", os.Getenv("GCM_GIT_BACKEND"), "git backend: exec (default) or go-git")
+	flag.StringVar(&opts.Type, "type", "", "commit type, e.g. feat or fix (non-interactive)")
+	flag.StringVar(&opts.Scope, "scope", "", "commit scope (non-interactive)")
+	flag.StringVar(&opts.Title, "title", "", "commit title (non-interactive)")
+	flag.StringVar(&opts.Body, "body", "", "commit body (non-interactive)")
+	flag.BoolVar(&opts.Breaking, "breaking", false, "mark the commit as a breaking change with '!' (non-interactive)")
+	flag.StringVar(&opts.BreakingChange, "breaking-change", "", "text of the BREAKING CHANGE footer, required with --breaking (non-interactive)")
+	flag.Func("trailer", "footer trailer such as 'Refs: #123' or 'Co-authored-by: Name <email>'; repeatable (non-interactive)", func(v string) error {
+		opts.Trailers = append(opts.Trailers, v)
+		return nil
+	})
+	flag.BoolVar(&opts.SignOff, "signoff", false, "add a Signed-off-by trailer with the git identity (non-interactive)")
+	flag.Func("files", "comma-separated paths to stage; repeatable (default: commit the index as-is)", func(v string) error {
+		for _, path := range strings.Split(v, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				opts.Files = append(opts.Files, path)
+			}
+		}
+		return nil
+	})
+	flag.StringVar(&opts.Branch, "branch", "", "branch to commit on, created if missing (non-interactive)")
+	flag.BoolVar(&opts.Push, "push", false, "push after committing (non-interactive)")
+	flag.BoolVar(&opts.Yes, "yes", false, "don't ask for confirmation (non-interactive)")
+	noDraft := flag.Bool("no-draft", false, "don't draft the commit message even if draft.endpoint is configured")
+	flag.Parse()
+
+	cfg, repo := open(*backend)
+
+	// Any commit flag switches to the non-interactive mode, which reports
+	// the required ones missing
+	scripted := false
+	flag.Visit(func(f *flag.Flag) {
+		scripted = scripted || f.Name != "backend" && f.Name != "no-draft"
+	})
+	if scripted {
+		dir, err := os.Getwd()
+		if err != nil {
+			exit(err)
+		}
+		opts.Dir = dir
+		exit(workflow.RunScripted(repo, cfg, opts, os.Stdin, os.Stdout))
 		return
 	}
 
-	// Step 2: Branch management
-	currentBranch, err := gitpkg.GetCurrentBranch()
+	linter, err := workflow.NewLinter(repo, cfg)
 	if err != nil {
-		fmt.Println("❌ Error getting current branch:", err)
+		fmt.Println("❌ Error", err)
 		os.Exit(1)
 	}
 
-	isMainBranch := gitpkg.IsMainBranch(currentBranch)
+	// All steps share one program; output, git's included, is printed
+	// above the current step
+	screen := ui.StartScreen()
+	switch r := repo.(type) {
+	case *gitpkg.ExecRepository:
+		r.Stdout, r.Stderr, r.Terminal = screen, screen, screen
+	case *gitpkg.GoGitRepository:
+		r.Stdout = screen
+	}
 
-	branchName, confirmed, err := ui.RunBranchSelection(currentBranch, isMainBranch)
+	session := &workflow.Session{
+		Repo:   repo,
+		UI:     workflow.Terminal{Config: cfg, Lint: linter, Screen: screen},
+		Out:    screen,
+		Config: cfg,
+	}
+	if cfg.Draft.Enabled() && !*noDraft {
+		session.Drafter = draft.New(cfg)
+	}
+
+	err = session.Run()
+	if closeErr := screen.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		fmt.Println("❌ Error in branch selection:", err)
+		fmt.Println("❌ Error", err)
 		os.Exit(1)
 	}
+}
 
-	if !confirmed {
-		fmt.Println("Canceled.")
-		return
-	}
+// lintMain runs `gcm lint [--file path] [range]`.
+func lintMain(args []string) {
+	var opts workflow.LintOptions
 
-	// Create new branch if needed
-	if branchName != currentBranch {
-		err = gitpkg.CreateBranch(branchName)
-		if err != nil {
-			fmt.Println("❌ Error creating branch:", err)
-			os.Exit(1)
-		}
-		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Created and switched to branch '%s'", branchName)))
-	} else {
-		fmt.Println(infoStyle.Render(fmt.Sprintf("📌 Using branch '%s'", branchName)))
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gcm lint [flags] <range>, e.g. gcm lint origin/main..HEAD")
+		fs.PrintDefaults()
 	}
+	backend := fs.String("backend", os.Getenv("GCM_GIT_BACKEND"), "git backend: exec (default) or go-git")
+	fs.StringVar(&opts.File, "file", "", "lint a message file instead of commits, '-' for stdin")
+	fs.Parse(args)
 
-	// Track commits created in this session
-	var commitsCreated []string
-
-	// Step 3: Loop until no more changes or user quits
-	for {
-		// Re-check for changes
-		output, err := gitpkg.CheckChangedFiles()
-		if err != nil {
-			fmt.Println("❌ Error checking changed files:", err)
-			break
-		}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(workflow.ExitUsage)
+	}
+	opts.Range = fs.Arg(0)
 
-		changesList = changes.ParseChangedFiles(output)
+	cfg, repo := open(*backend)
 
-		if len(changesList) == 0 {
-			fmt.Println(successStyle.Render("\n✨ All files committed!"))
-			break
-		}
+	linter, err := workflow.NewLinter(repo, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌ Error:", err)
+		os.Exit(workflow.ExitUsage)
+	}
 
-		fmt.Printf("\n%s\n", infoStyle.Render(fmt.Sprintf("📋 %d file(s) with changes", len(changesList))))
+	exit(workflow.RunLint(repo, linter, opts, os.Stdin, os.Stdout))
+}
 
-		// Step 4: File selection
-		selected, err := ui.Run(changesList)
-		if err != nil {
-			fmt.Println("❌ Error running UI:", err)
-			break
-		}
+// hookMain runs `gcm hook install|uninstall` and, from the installed
+// scripts, `gcm hook run <hook> <args>`.
+func hookMain(args []string) {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gcm hook install [--force] | uninstall | run <hook> <args>")
+		fs.PrintDefaults()
+	}
+	backend := fs.String("backend", os.Getenv("GCM_GIT_BACKEND"), "git backend: exec (default) or go-git")
+	force := fs.Bool("force", false, "replace hooks not installed by gcm")
 
-		if selected == nil || len(selected) == 0 {
-			fmt.Println("No files selected, exiting.")
-			break
-		}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(workflow.ExitUsage)
+	}
+	action := args[0]
+	fs.Parse(args[1:])
 
-		// Show warning for large commits
-		if len(selected) > 10 {
-			fmt.Println(warningStyle.Render(fmt.Sprintf("⚠️  Warning: Large commit with %d files", len(selected))))
-		}
+	cfg, repo := open(*backend)
 
-		// Step 5: Commit type selection
-		commitType, isCustom, err := ui.RunCommitTypeSelection()
+	switch action {
+	case "install":
+		exe, err := os.Executable()
 		if err != nil {
-			fmt.Println("❌ Error selecting commit type:", err)
-			break
+			exit(err)
 		}
+		exit(workflow.InstallHooks(repo, exe, *force, os.Stdout))
 
-		if commitType == "" && !isCustom {
-			fmt.Println("No commit type selected, exiting.")
-			break
+	case "uninstall":
+		exit(workflow.UninstallHooks(repo, os.Stdout))
+
+	case "run":
+		if fs.NArg() < 2 {
+			fs.Usage()
+			os.Exit(workflow.ExitUsage)
 		}
 
-		// Handle custom commit type
-		if isCustom {
-			customType, ok, err := ui.GetInput("Enter custom commit type:")
+		switch hook, file := fs.Arg(0), fs.Arg(1); hook {
+		case workflow.HookCommitMsg:
+			linter, err := workflow.NewLinter(repo, cfg)
 			if err != nil {
-				fmt.Println("❌ Error getting custom type:", err)
-				break
-			}
-			if !ok || customType == "" {
-				fmt.Println("No custom type provided, exiting.")
-				break
+				fmt.Fprintln(os.Stderr, "❌ Error:", err)
+				os.Exit(workflow.ExitUsage)
 			}
-			commitType = customType
+			exit(workflow.RunCommitMsgHook(linter, file, workflow.CommentChar(repo), os.Stderr))
+		case workflow.HookPrepareCommitMsg:
+			exit(workflow.RunPrepareCommitMsgHook(repo, cfg, file, fs.Arg(2)))
+		default:
+			fmt.Fprintf(os.Stderr, "❌ Error: unknown hook %q\n", hook)
+			os.Exit(workflow.ExitUsage)
 		}
 
-		// Step 6: Commit message
-		title, description, confirmed, err := ui.RunCommitMessage(commitType)
-		if err != nil {
-			fmt.Println("❌ Error getting commit message:", err)
-			break
-		}
-
-		if !confirmed {
-			fmt.Println("Commit message not confirmed, exiting.")
-			break
-		}
-
-		// Step 7: Stage files
-		var paths []string
-		for _, it := range selected {
-			paths = append(paths, it.Path)
-		}
-
-		err = gitpkg.Add(paths)
-		if err != nil {
-			fmt.Println("❌ Error during git add:", err)
-			break
-		}
-
-		// Step 8: Commit
-		fullMessage := fmt.Sprintf("%s: %s", commitType, title)
-		if description != "" {
-			fullMessage = fmt.Sprintf("%s\n\n%s", fullMessage, description)
-		}
-
-		err = gitpkg.Commit(fullMessage)
-		if err != nil {
-			fmt.Println("❌ Error during git commit:", err)
-			break
-		}
-
-		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Commit created: [%s] %s", commitType, title)))
-		commitsCreated = append(commitsCreated, fmt.Sprintf("[%s] %s", commitType, title))
-
-		// Step 9: Check if there are more uncommitted files
-		output, err = gitpkg.CheckChangedFiles()
-		if err != nil {
-			break
-		}
-
-		remainingChanges := changes.ParseChangedFiles(output)
+	default:
+		fs.Usage()
+		os.Exit(workflow.ExitUsage)
+	}
+}
 
-		if len(remainingChanges) == 0 {
-			fmt.Println(successStyle.Render("\n✨ All files committed!"))
-			break
-		}
+// changelogMain runs `gcm changelog`.
+func changelogMain(args []string) {
+	var opts workflow.ChangelogOptions
+
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	backend := fs.String("backend", os.Getenv("GCM_GIT_BACKEND"), "git backend: exec (default) or go-git")
+	fs.StringVar(&opts.From, "from", "", "start tag (default: the latest version tag)")
+	fs.StringVar(&opts.To, "to", "", "end revision (default: HEAD)")
+	fs.StringVar(&opts.Version, "version", "", "version heading (default: --to if it is a version tag, else Unreleased)")
+	fs.BoolVar(&opts.All, "all", false, "include every commit type, not only features, fixes and performance")
+	fs.BoolVar(&opts.JSON, "json", false, "print the release as JSON")
+	fs.BoolVar(&opts.Prepend, "prepend", false, "write the release at the top of the changelog file")
+	fs.StringVar(&opts.File, "file", "CHANGELOG.md", "changelog file for --prepend")
+	fs.Parse(args)
+
+	cfg, repo := open(*backend)
+	exit(workflow.RunChangelog(repo, cfg, opts, os.Stdout))
+}
 
-		// Ask if user wants to continue
-		fmt.Printf("\n%s\n", infoStyle.Render(fmt.Sprintf("Current status:")))
-		fmt.Printf("  - %d file(s) committed\n", len(selected))
-		fmt.Printf("  - %d file(s) still uncommitted\n\n", len(remainingChanges))
+// releaseMain runs `gcm release`.
+func releaseMain(args []string) {
+	var opts workflow.ReleaseOptions
+
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	backend := fs.String("backend", os.Getenv("GCM_GIT_BACKEND"), "git backend: exec (default) or go-git")
+	fs.StringVar(&opts.Pre, "pre", "", "pre-release channel, e.g. rc for vX.Y.Z-rc.N")
+	fs.BoolVar(&opts.Sign, "sign", false, "GPG-sign the tag")
+	fs.BoolVar(&opts.Push, "push", false, "push the tag after creating it")
+	fs.BoolVar(&opts.Yes, "yes", false, "don't ask for confirmation")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "only print the next version and its commits")
+	fs.Parse(args)
+
+	cfg, repo := open(*backend)
+	exit(workflow.RunRelease(repo, cfg, workflow.Terminal{Config: cfg}, opts, os.Stdout))
+}
 
-		continueCommit, err := ui.Confirm("Want to create another commit on the same branch?", "(y/n)")
-		if err != nil || !continueCommit {
-			break
-		}
+// open loads the configuration and opens the repository with the backend
+// from the flag or, failing that, the configuration.
+func open(backend string) (config.Config, gitpkg.Repository) {
+	cfg, err := config.Load(".")
+	if err != nil {
+		fmt.Println("❌ Error loading configuration:", err)
+		os.Exit(1)
 	}
 
-	// Step 10: Show summary and offer push
-	if len(commitsCreated) > 0 {
-		fmt.Println("\n" + strings.Repeat("─", 50))
-		fmt.Println(successStyle.Render("📦 Commits created in this session:"))
-		for i, commit := range commitsCreated {
-			fmt.Printf("  %d. %s\n", i+1, commit)
-		}
-		fmt.Println(strings.Repeat("─", 50))
+	if backend == "" {
+		backend = cfg.Backend
+	}
 
-		// Offer to push
-		shouldPush, err := ui.Confirm("\nPush to remote?", "(y/n)")
-		if err == nil && shouldPush {
-			// Check if remote branch exists
-			hasRemote, _ := gitpkg.HasRemoteBranch(branchName)
+	repo, err := gitpkg.Open(backend, "", cfg.Remote)
+	if err != nil {
+		fmt.Println("❌ Error opening repository:", err)
+		os.Exit(1)
+	}
 
-			fmt.Printf("\n🚀 Pushing to origin/%s...\n", branchName)
+	return cfg, repo
+}
 
-			err = gitpkg.Push(branchName, !hasRemote)
-			if err != nil {
-				fmt.Println("❌ Error during push:", err)
-			} else {
-				fmt.Println(successStyle.Render("✓ Successfully pushed to remote"))
-			}
-		} else {
-			fmt.Println(infoStyle.Render("\n💡 You can push later with: git push origin " + branchName))
-		}
+// exit reports a failed scripted run and exits with its code.
+func exit(err error) {
+	if err == nil {
+		return
 	}
 
-	fmt.Println("\n" + successStyle.Render("✨ Done."))
+	fmt.Fprintln(os.Stderr, "❌ Error:", err)
+	code := workflow.ExitGit
+	var exitErr *workflow.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.Code
+	}
+	os.Exit(code)
 }